@@ -5,16 +5,22 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/utils"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/urfave/cli"
 )
 
+const execHistoryFilename = "exec.log"
+
 var execCommand = cli.Command{
 	Name:  "exec",
 	Usage: "execute new process inside the container",
@@ -93,41 +99,51 @@ following will output a list of processes running in the container:
 		if err := revisePidFile(context); err != nil {
 			return err
 		}
-		status, err := execProcess(context)
+		result, err := execProcess(context)
 		if err == nil {
-			os.Exit(status)
+			os.Exit(result.exitCode())
 		}
 		return fmt.Errorf("exec failed: %v", err)
 	},
 	SkipArgReorder: true,
 }
 
-func execProcess(context *cli.Context) (int, error) {
+func execProcess(context *cli.Context) (*runResult, error) {
 	container, err := getContainer(context)
 	if err != nil {
-		return -1, err
+		return nil, err
+	}
+	// resolve the history path now, getProcess changes into the bundle.
+	historyPath, err := execHistoryPath(context, container.ID())
+	if err != nil {
+		return nil, err
 	}
 	status, err := container.Status()
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
 	if status == libcontainer.Stopped {
-		return -1, fmt.Errorf("cannot exec a container that has stopped")
+		return nil, fmt.Errorf("cannot exec a container that has stopped")
+	}
+	if status == libcontainer.Paused {
+		return nil, fmt.Errorf("cannot exec a container that is paused, resume it first")
 	}
 	path := context.String("process")
 	if path == "" && len(context.Args()) == 1 {
-		return -1, fmt.Errorf("process args cannot be empty")
+		return nil, fmt.Errorf("process args cannot be empty")
 	}
 	detach := context.Bool("detach")
 	state, err := container.State()
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
 	bundle := utils.SearchLabels(state.Config.Labels, "bundle")
 	p, err := getProcess(context, bundle)
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
+	_, annotations := utils.Annotations(state.Config.Labels)
+	setupHostEnv(p, annotations)
 	r := &runner{
 		enableSubreaper: false,
 		shouldDestroy:   false,
@@ -137,7 +153,82 @@ func execProcess(context *cli.Context) (int, error) {
 		pidFile:         context.String("pid-file"),
 		action:          CT_ACT_RUN,
 	}
-	return r.run(p)
+	record := execRecord{
+		Args:    p.Args,
+		User:    fmt.Sprintf("%d:%d", p.User.UID, p.User.GID),
+		Started: time.Now().UTC(),
+	}
+	result, err := r.run(p)
+	if err != nil {
+		record.Error = err.Error()
+	} else if !result.Detached {
+		exited := time.Now().UTC()
+		record.Exited = &exited
+		record.ExitStatus = &result.Status
+	}
+	if herr := appendExecHistory(historyPath, record); herr != nil {
+		logrus.Warnf("recording exec history: %v", herr)
+	}
+	return result, err
+}
+
+// execRecord is an entry of a container's exec history. Exited and
+// ExitStatus are only known when runc waited for the process.
+type execRecord struct {
+	Args       []string   `json:"args"`
+	User       string     `json:"user"`
+	Started    time.Time  `json:"started"`
+	Exited     *time.Time `json:"exited,omitempty"`
+	ExitStatus *int       `json:"exit_status,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// execHistoryPath returns the path of the exec history journal, which is
+// kept in the container's state directory and removed along with it.
+func execHistoryPath(context *cli.Context, id string) (string, error) {
+	root, err := filepath.Abs(context.GlobalString("root"))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, id, execHistoryFilename), nil
+}
+
+func appendExecHistory(path string, record execRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// a single write keeps concurrent appends from interleaving.
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func readExecHistory(path string) ([]execRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var records []execRecord
+	dec := json.NewDecoder(f)
+	for {
+		var record execRecord
+		if err := dec.Decode(&record); err != nil {
+			if err == io.EOF {
+				return records, nil
+			}
+			return nil, fmt.Errorf("parsing exec history %s: %v", path, err)
+		}
+		records = append(records, record)
+	}
 }
 
 func getProcess(context *cli.Context, bundle string) (*specs.Process, error) {
@@ -47,6 +47,11 @@ func (s Status) String() string {
 // BaseState represents the platform agnostic pieces relating to a
 // running container's state
 type BaseState struct {
+	// SchemaVersion is the version of the state format. Fields may be added
+	// without bumping it, it only changes when older versions of libcontainer
+	// could no longer make sense of the state.
+	SchemaVersion int `json:"schema_version"`
+
 	// ID is the container ID.
 	ID string `json:"id"`
 
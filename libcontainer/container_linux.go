@@ -33,6 +33,10 @@ import (
 
 const stdioFdCount = 3
 
+// stateSchemaVersion is the version of the state format written by this
+// version of libcontainer, see BaseState.SchemaVersion.
+const stateSchemaVersion = 1
+
 type linuxContainer struct {
 	id                   string
 	root                 string
@@ -46,6 +50,9 @@ type linuxContainer struct {
 	criuVersion          int
 	state                containerState
 	created              time.Time
+	// unknownState holds the state fields written by a newer libcontainer,
+	// so they are kept when the state is saved again.
+	unknownState map[string]json.RawMessage
 }
 
 // State represents a running container's state
@@ -67,6 +74,84 @@ type State struct {
 
 	// Container's standard descriptors (std{in,out,err}), needed for checkpoint and restore
 	ExternalDescriptors []string `json:"external_descriptors,omitempty"`
+
+	// unknown holds the top level fields this version does not know about.
+	unknown map[string]json.RawMessage
+}
+
+// UnmarshalJSON decodes the state, keeping any top level fields that are not
+// part of State so that a state written by a newer libcontainer can be saved
+// again without losing them.
+func (s *State) UnmarshalJSON(data []byte) error {
+	// state has the fields but not the methods of State.
+	type state State
+	if err := json.Unmarshal(data, (*state)(s)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	known := jsonFieldNames(reflect.TypeOf(*s))
+	for name := range fields {
+		// encoding/json matches field names case insensitively.
+		if known[strings.ToLower(name)] {
+			delete(fields, name)
+		}
+	}
+	s.unknown = nil
+	if len(fields) > 0 {
+		s.unknown = fields
+	}
+	return nil
+}
+
+// MarshalJSON encodes the state along with any unknown fields it was decoded
+// with.
+func (s State) MarshalJSON() ([]byte, error) {
+	type state State
+	data, err := json.Marshal(state(s))
+	if err != nil || len(s.unknown) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range s.unknown {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// jsonFieldNames returns the lower cased JSON names of the fields of the
+// struct type t, including those of embedded structs.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for n := range jsonFieldNames(f.Type) {
+				names[n] = true
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[strings.ToLower(name)] = true
+	}
+	return names
 }
 
 // Container is a libcontainer container object.
@@ -193,39 +278,53 @@ func (c *linuxContainer) Set(config configs.Config) error {
 func (c *linuxContainer) Start(process *Process) error {
 	c.m.Lock()
 	defer c.m.Unlock()
-	status, err := c.currentStatus()
+	_, err := c.startProcess(process)
+	return err
+}
+
+// Run starts process like Start and, if it is the container's init, then
+// waits for the init to be ready and execs the user process. The lock is held
+// while the process is started, so that the status it is started for cannot
+// change underneath it, but not while waiting on the exec fifo: that open
+// blocks until the init opens the other end and would otherwise block every
+// other operation on the container, such as Destroy, if the init dies first.
+func (c *linuxContainer) Run(process *Process) error {
+	c.m.Lock()
+	status, err := c.startProcess(process)
+	c.m.Unlock()
 	if err != nil {
 		return err
 	}
 	if status == Stopped {
-		if err := c.createExecFifo(); err != nil {
-			return err
-		}
-	}
-	if err := c.start(process, status == Stopped); err != nil {
-		if status == Stopped {
-			c.deleteExecFifo()
-		}
-		return err
+		return c.exec()
 	}
 	return nil
 }
 
-func (c *linuxContainer) Run(process *Process) error {
-	c.m.Lock()
+// startProcess starts process as the container's init if the container is
+// stopped, or as an additional process otherwise. It returns the status the
+// container was in beforehand. The caller must hold c.m.
+func (c *linuxContainer) startProcess(process *Process) (Status, error) {
 	status, err := c.currentStatus()
 	if err != nil {
-		c.m.Unlock()
-		return err
+		return status, err
 	}
-	c.m.Unlock()
-	if err := c.Start(process); err != nil {
-		return err
+	if status == Paused {
+		// a new process would join the frozen cgroup and hang.
+		return status, newGenericError(fmt.Errorf("container is paused"), ContainerPaused)
 	}
 	if status == Stopped {
-		return c.exec()
+		if err := c.createExecFifo(); err != nil {
+			return status, err
+		}
 	}
-	return nil
+	if err := c.start(process, status == Stopped); err != nil {
+		if status == Stopped {
+			c.deleteExecFifo()
+		}
+		return status, err
+	}
+	return status, nil
 }
 
 func (c *linuxContainer) Exec() error {
@@ -322,7 +421,12 @@ func (c *linuxContainer) createExecFifo() error {
 
 	fifoName := filepath.Join(c.root, execFifoFilename)
 	if _, err := os.Stat(fifoName); err == nil {
-		return fmt.Errorf("exec fifo %s already exists", fifoName)
+		// The fifo is only created for a stopped container, so an existing
+		// one was left behind by an init that died before being started.
+		logrus.Warnf("removing stale exec fifo %s", fifoName)
+		if err := os.Remove(fifoName); err != nil {
+			return newSystemErrorWithCause(err, "removing stale exec fifo")
+		}
 	}
 	oldMask := syscall.Umask(0000)
 	if err := syscall.Mkfifo(fifoName, 0622); err != nil {
@@ -1438,6 +1542,7 @@ func (c *linuxContainer) currentState() (*State, error) {
 	}
 	state := &State{
 		BaseState: BaseState{
+			SchemaVersion:        stateSchemaVersion,
 			ID:                   c.ID(),
 			Config:               *c.config,
 			InitProcessPid:       pid,
@@ -1448,6 +1553,7 @@ func (c *linuxContainer) currentState() (*State, error) {
 		CgroupPaths:         c.cgroupManager.GetPaths(),
 		NamespacePaths:      make(map[configs.NamespaceType]string),
 		ExternalDescriptors: externalDescriptors,
+		unknown:             c.unknownState,
 	}
 	if pid > 0 {
 		for _, ns := range c.config.Namespaces {
@@ -4,11 +4,14 @@ package libcontainer
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/system"
 )
 
 type mockCgroupManager struct {
@@ -216,3 +219,123 @@ func TestGetContainerState(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateExecFifoStale(t *testing.T) {
+	root, err := ioutil.TempDir("", "libcontainer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	fifoName := filepath.Join(root, execFifoFilename)
+	if err := ioutil.WriteFile(fifoName, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	container := &linuxContainer{
+		id:     "myid",
+		root:   root,
+		config: &configs.Config{},
+	}
+	if err := container.createExecFifo(); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(fifoName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&os.ModeNamedPipe == 0 {
+		t.Fatalf("expected stale exec fifo to be replaced by a fifo but got mode %s", fi.Mode())
+	}
+}
+
+func TestStartPausedContainer(t *testing.T) {
+	freezer, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(freezer)
+	if err := ioutil.WriteFile(filepath.Join(freezer, "freezer.state"), []byte("FROZEN\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	container := &linuxContainer{
+		id:     "myid",
+		config: &configs.Config{},
+		cgroupManager: &mockCgroupManager{
+			paths: map[string]string{
+				"freezer": freezer,
+			},
+		},
+		initProcess: &mockProcess{
+			_pid: os.Getpid(),
+		},
+	}
+	container.state = &runningState{c: container}
+	err = container.Start(&Process{})
+	if err == nil {
+		t.Fatal("expected error starting a process in a paused container")
+	}
+	lerr, ok := err.(Error)
+	if !ok {
+		t.Fatal("expected libcontainer error type")
+	}
+	if lerr.Code() != ContainerPaused {
+		t.Fatalf("expected error code %s but received %s", ContainerPaused, lerr.Code())
+	}
+	if container.state.status() != Paused {
+		t.Fatalf("expected container status %s but received %s", Paused, container.state.status())
+	}
+}
+
+func TestExecFifoTransitionsToRunning(t *testing.T) {
+	root, err := ioutil.TempDir("", "libcontainer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	started, err := system.GetProcessStartTime(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := &linuxContainer{
+		id:                   "myid",
+		root:                 root,
+		config:               &configs.Config{},
+		cgroupManager:        &mockCgroupManager{},
+		initProcess:          &mockProcess{_pid: os.Getpid(), started: started},
+		initProcessStartTime: started,
+	}
+	container.state = &createdState{c: container}
+	if err := container.createExecFifo(); err != nil {
+		t.Fatal(err)
+	}
+	status, err := container.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != Created {
+		t.Fatalf("expected container status %s but received %s", Created, status)
+	}
+
+	// stand in for the container's init, which writes to the fifo once it
+	// is ready to exec the user process.
+	go func() {
+		f, err := os.OpenFile(filepath.Join(root, execFifoFilename), os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		f.Write([]byte("0"))
+		f.Close()
+	}()
+	if err := container.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	status, err = container.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != Running {
+		t.Fatalf("expected container status %s but received %s", Running, status)
+	}
+	if _, err := os.Stat(filepath.Join(root, execFifoFilename)); !os.IsNotExist(err) {
+		t.Fatalf("expected exec fifo to be removed after exec: %v", err)
+	}
+}
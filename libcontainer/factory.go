@@ -6,8 +6,9 @@ import (
 
 type Factory interface {
 	// Creates a new container with the given id and starts the initial process inside it.
-	// id must be a string containing only letters, digits and underscores and must contain
-	// between 1 and 1024 characters, inclusive.
+	// id must be a string containing only ASCII letters, digits, underscores and the
+	// characters '+', ',', '-' and '.', must contain between 1 and 255 characters, inclusive, and must not
+	// be "." or "..".
 	//
 	// The id must not already be in use by an existing container. Containers created using
 	// a factory with the same path (and filesystem) must have distinct ids.
@@ -27,6 +28,7 @@ type Factory interface {
 	// from the state.  This presents a read only view of the container.
 	//
 	// errors:
+	// InvalidIdFormat - id has incorrect format
 	// Path does not exist
 	// System error
 	Load(id string) (Container, error)
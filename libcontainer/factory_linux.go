@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"syscall"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/mount"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/cgroups/fs"
@@ -29,6 +30,10 @@ const (
 
 var idRegex = regexp.MustCompile(`^[\w+-\.]+$`)
 
+// maxIDLength is the longest id accepted, as the id is used as the name of
+// the container's state directory and may not exceed NAME_MAX.
+const maxIDLength = 255
+
 // InitArgs returns an options func to configure a LinuxFactory with the
 // provided init binary path and arguments.
 func InitArgs(args ...string) func(*LinuxFactory) error {
@@ -92,7 +97,7 @@ func TmpfsRoot(l *LinuxFactory) error {
 		return err
 	}
 	if !mounted {
-		if err := syscall.Mount("tmpfs", l.Root, "tmpfs", 0, ""); err != nil {
+		if err := syscall.Mount("tmpfs", l.Root, "tmpfs", 0, "mode=0700"); err != nil {
 			return err
 		}
 	}
@@ -108,6 +113,46 @@ func CriuPath(criupath string) func(*LinuxFactory) error {
 	}
 }
 
+// UnsafeRoot is an options func to configure a LinuxFactory to only warn,
+// instead of failing, when its root directory could be tampered with by
+// other users.
+func UnsafeRoot(l *LinuxFactory) error {
+	l.AllowUnsafeRoot = true
+	return nil
+}
+
+// Umask returns an option func that sets the umask of the calling process,
+// which applies to the files and directories created while setting up
+// containers, instead of relying on the umask runc was started with.
+func Umask(mask int) func(*LinuxFactory) error {
+	return func(l *LinuxFactory) error {
+		syscall.Umask(mask)
+		return nil
+	}
+}
+
+// NofileLimit returns an option func that sets the RLIMIT_NOFILE soft limit of
+// the calling process to limit, raising the hard limit as well if needed.
+// Setting up containers with many mounts can need more descriptors than an
+// inherited limit allows. The container's init inherits the limit unless its
+// config sets RLIMIT_NOFILE itself.
+func NofileLimit(limit uint64) func(*LinuxFactory) error {
+	return func(l *LinuxFactory) error {
+		var rlim syscall.Rlimit
+		if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlim); err != nil {
+			return newSystemErrorWithCause(err, "getting RLIMIT_NOFILE")
+		}
+		rlim.Cur = limit
+		if rlim.Max < limit {
+			rlim.Max = limit
+		}
+		if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &rlim); err != nil {
+			return newSystemErrorWithCausef(err, "setting RLIMIT_NOFILE to %d", limit)
+		}
+		return nil
+	}
+}
+
 // New returns a linux based container factory based in the root directory and
 // configures the factory with the provided option funcs.
 func New(root string, options ...func(*LinuxFactory) error) (Factory, error) {
@@ -128,6 +173,17 @@ func New(root string, options ...func(*LinuxFactory) error) (Factory, error) {
 			return nil, err
 		}
 	}
+	if root != "" {
+		// options such as TmpfsRoot may have replaced the root directory,
+		// so only check it and bring its layout up to date once they have
+		// been applied.
+		if err := checkRoot(root, l.AllowUnsafeRoot); err != nil {
+			return nil, newGenericError(err, ConfigInvalid)
+		}
+		if err := migrateLayout(root); err != nil {
+			return nil, newGenericError(err, SystemError)
+		}
+	}
 	return l, nil
 }
 
@@ -149,6 +205,10 @@ type LinuxFactory struct {
 
 	// NewCgroupsManager returns an initialized cgroups manager for a single container.
 	NewCgroupsManager func(config *configs.Cgroup, paths map[string]string) cgroups.Manager
+
+	// AllowUnsafeRoot only warns about a root directory that is writable by
+	// other users or not owned by the current user instead of failing.
+	AllowUnsafeRoot bool
 }
 
 func (l *LinuxFactory) Create(id string, config *configs.Config) (Container, error) {
@@ -170,12 +230,12 @@ func (l *LinuxFactory) Create(id string, config *configs.Config) (Container, err
 		return nil, newGenericError(err, SystemError)
 	}
 	containerRoot := filepath.Join(l.Root, id)
-	if _, err := os.Stat(containerRoot); err == nil {
-		return nil, newGenericError(fmt.Errorf("container with id exists: %v", id), IdInUse)
-	} else if !os.IsNotExist(err) {
-		return nil, newGenericError(err, SystemError)
-	}
-	if err := os.MkdirAll(containerRoot, 0711); err != nil {
+	// creating the state directory claims the id, so concurrent creates with
+	// the same id cannot both succeed.
+	if err := os.Mkdir(containerRoot, 0711); err != nil {
+		if os.IsExist(err) {
+			return nil, newGenericError(fmt.Errorf("container with id exists: %v", id), IdInUse)
+		}
 		return nil, newGenericError(err, SystemError)
 	}
 	if err := os.Chown(containerRoot, uid, gid); err != nil {
@@ -200,6 +260,9 @@ func (l *LinuxFactory) Load(id string) (Container, error) {
 	if l.Root == "" {
 		return nil, newGenericError(fmt.Errorf("invalid root"), ConfigInvalid)
 	}
+	if err := l.validateID(id); err != nil {
+		return nil, err
+	}
 	containerRoot := filepath.Join(l.Root, id)
 	state, err := l.loadState(containerRoot, id)
 	if err != nil {
@@ -224,6 +287,7 @@ func (l *LinuxFactory) Load(id string) (Container, error) {
 		cgroupManager:        l.NewCgroupsManager(state.Config.Cgroups, state.CgroupPaths),
 		root:                 containerRoot,
 		created:              state.Created,
+		unknownState:         state.unknown,
 	}
 	c.state = &loadedState{c: c}
 	if err := c.refreshState(); err != nil {
@@ -320,6 +384,12 @@ func (l *LinuxFactory) loadState(root, id string) (*State, error) {
 	if err := json.NewDecoder(f).Decode(&state); err != nil {
 		return nil, newGenericError(err, SystemError)
 	}
+	if state == nil {
+		return nil, newGenericError(fmt.Errorf("container %q has an empty state", id), SystemError)
+	}
+	if state.SchemaVersion > stateSchemaVersion {
+		return nil, newGenericError(fmt.Errorf("container %q has state schema version %d, this version of libcontainer only supports up to %d", id, state.SchemaVersion, stateSchemaVersion), SystemError)
+	}
 	return state, nil
 }
 
@@ -327,6 +397,55 @@ func (l *LinuxFactory) validateID(id string) error {
 	if !idRegex.MatchString(id) {
 		return newGenericError(fmt.Errorf("invalid id format: %v", id), InvalidIdFormat)
 	}
+	// the id is joined onto the root, which must not escape it.
+	if id == "." || id == ".." {
+		return newGenericError(fmt.Errorf("invalid id format: %v", id), InvalidIdFormat)
+	}
+	if len(id) > maxIDLength {
+		return newGenericError(fmt.Errorf("id is longer than %d characters", maxIDLength), InvalidIdFormat)
+	}
+
+	return nil
+}
+
+// Filesystem magic numbers of network filesystems, see statfs(2).
+const (
+	nfsSuperMagic   = 0x6969
+	smbSuperMagic   = 0x517b
+	cifsMagic       = 0xff534d42
+	smb2MagicNumber = 0xfe534d42
+)
 
+// checkRoot makes sure that the state stored in root cannot be tampered
+// with by other users, which would allow them to make runc act on forged
+// containers. Unless unsafe is set a root that is not owned by the current
+// user or that is writable by others is refused.
+func checkRoot(root string, unsafe bool) error {
+	var st syscall.Stat_t
+	if err := syscall.Stat(root, &st); err != nil {
+		return err
+	}
+	var problem string
+	switch {
+	case int(st.Uid) != os.Geteuid():
+		problem = fmt.Sprintf("root %s is owned by uid %d, not by the current user", root, st.Uid)
+	case st.Mode&(syscall.S_IWGRP|syscall.S_IWOTH) != 0:
+		problem = fmt.Sprintf("root %s is writable by other users (mode %#o)", root, st.Mode&07777)
+	}
+	if problem != "" {
+		if !unsafe {
+			return fmt.Errorf("%s", problem)
+		}
+		logrus.Warn(problem)
+	}
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(root, &fs); err != nil {
+		return err
+	}
+	switch uint32(fs.Type) {
+	case nfsSuperMagic, smbSuperMagic, cifsMagic, smb2MagicNumber:
+		// locking and fifos are unreliable on network filesystems.
+		logrus.Warnf("root %s is on a network filesystem, it should be on a local one such as tmpfs", root)
+	}
 	return nil
 }
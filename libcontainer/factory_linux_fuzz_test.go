@@ -0,0 +1,43 @@
+// +build linux,go1.18
+
+package libcontainer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func FuzzFactoryLoad(f *testing.F) {
+	f.Add([]byte(`{"id":"fuzz","init_process_pid":1024,"config":{"rootfs":"/mycontainer/root"}}`))
+	f.Add([]byte(`{"id":"fuzz","rootless":true,"cgroup_paths":{"freezer":"/nonexistent"}}`))
+	f.Add([]byte(`null`))
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"config":{"hooks":{"prestart":[{}]}}}`))
+	f.Add([]byte(``))
+	root, err := newTestRoot()
+	if err != nil {
+		f.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	factory, err := New(root, Cgroupfs)
+	if err != nil {
+		f.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "fuzz"), 0700); err != nil {
+		f.Fatal(err)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// a corrupt or hostile state.json must be reported, not panic.
+		if err := ioutil.WriteFile(filepath.Join(root, "fuzz", stateFilename), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		container, err := factory.Load("fuzz")
+		if err != nil {
+			return
+		}
+		container.Status()
+		container.State()
+	})
+}
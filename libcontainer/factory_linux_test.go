@@ -3,15 +3,19 @@
 package libcontainer
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"syscall"
 	"testing"
 
 	"github.com/docker/docker/pkg/mount"
 	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/system"
 	"github.com/opencontainers/runc/libcontainer/utils"
 )
 
@@ -102,6 +106,196 @@ func TestFactoryNewTmpfs(t *testing.T) {
 	defer syscall.Unmount(root, syscall.MNT_DETACH)
 }
 
+func TestFactoryNewLayoutVersion(t *testing.T) {
+	root, rerr := newTestRoot()
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	defer os.RemoveAll(root)
+	if _, err := New(root, Cgroupfs); err != nil {
+		t.Fatal(err)
+	}
+	version, err := readLayoutVersion(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != layoutVersion {
+		t.Fatalf("expected layout version %d but received %d", layoutVersion, version)
+	}
+}
+
+func TestFactoryNewNewerLayoutVersion(t *testing.T) {
+	root, rerr := newTestRoot()
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	defer os.RemoveAll(root)
+	if err := writeLayoutVersion(root, layoutVersion+1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(root, Cgroupfs); err == nil {
+		t.Fatal("expected error creating factory on a root with a newer layout version")
+	}
+}
+
+func TestFactoryNewInvalidLayoutVersion(t *testing.T) {
+	root, rerr := newTestRoot()
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	defer os.RemoveAll(root)
+	for _, version := range []string{"-1\n", "one\n"} {
+		if err := ioutil.WriteFile(filepath.Join(root, layoutVersionFilename), []byte(version), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := New(root, Cgroupfs); err == nil {
+			t.Fatalf("expected error creating factory on a root with layout version %q", version)
+		}
+	}
+}
+
+func TestFactoryNewUnsafeRoot(t *testing.T) {
+	root, rerr := newTestRoot()
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	defer os.RemoveAll(root)
+	if err := os.Chmod(root, 0777); err != nil {
+		t.Fatal(err)
+	}
+	_, err := New(root, Cgroupfs)
+	if err == nil {
+		t.Fatal("expected error creating factory on a world writable root")
+	}
+	lerr, ok := err.(Error)
+	if !ok {
+		t.Fatal("expected libcontainer error type")
+	}
+	if lerr.Code() != ConfigInvalid {
+		t.Fatalf("expected error code %s but received %s", ConfigInvalid, lerr.Code())
+	}
+	if _, err := New(root, Cgroupfs, UnsafeRoot); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFactoryCreateDuplicateID(t *testing.T) {
+	root, rerr := newTestRoot()
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	defer os.RemoveAll(root)
+	factory, err := New(root, Cgroupfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &configs.Config{Rootfs: root}
+	const attempts = 8
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		go func() {
+			_, err := factory.Create("dup", config)
+			errs <- err
+		}()
+	}
+	created := 0
+	for i := 0; i < attempts; i++ {
+		err := <-errs
+		if err == nil {
+			created++
+			continue
+		}
+		lerr, ok := err.(Error)
+		if !ok {
+			t.Fatalf("expected libcontainer error type but received %v", err)
+		}
+		if lerr.Code() != IdInUse {
+			t.Fatalf("expected error code %s but received %s", IdInUse, lerr.Code())
+		}
+	}
+	if created != 1 {
+		t.Fatalf("expected exactly one container to be created but %d were", created)
+	}
+}
+
+func TestFactoryNewUmask(t *testing.T) {
+	root, rerr := newTestRoot()
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	defer os.RemoveAll(root)
+	defer syscall.Umask(syscall.Umask(0))
+	if _, err := New(root, Cgroupfs, Umask(0027)); err != nil {
+		t.Fatal(err)
+	}
+	if mask := syscall.Umask(0); mask != 0027 {
+		t.Fatalf("expected umask %#o but got %#o", 0027, mask)
+	}
+}
+
+func TestFactoryNewNofileLimit(t *testing.T) {
+	root, rerr := newTestRoot()
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	defer os.RemoveAll(root)
+	var orig syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &orig); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Setrlimit(syscall.RLIMIT_NOFILE, &orig)
+	limit := orig.Cur - 1
+	if _, err := New(root, Cgroupfs, NofileLimit(limit)); err != nil {
+		t.Fatal(err)
+	}
+	var rlim syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlim); err != nil {
+		t.Fatal(err)
+	}
+	if rlim.Cur != limit {
+		t.Fatalf("expected RLIMIT_NOFILE soft limit %d but got %d", limit, rlim.Cur)
+	}
+	if rlim.Max != orig.Max {
+		t.Fatalf("expected RLIMIT_NOFILE hard limit to stay %d but got %d", orig.Max, rlim.Max)
+	}
+}
+
+func TestFactoryCreateInvalidID(t *testing.T) {
+	root, rerr := newTestRoot()
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	defer os.RemoveAll(root)
+	factory, err := New(root, Cgroupfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &configs.Config{Rootfs: root}
+	for _, id := range []string{
+		"",
+		".",
+		"..",
+		"a/b",
+		"caf\u00e9",
+		strings.Repeat("a", maxIDLength+1),
+	} {
+		_, err := factory.Create(id, config)
+		if err == nil {
+			t.Fatalf("expected error creating container with id %q", id)
+		}
+		lerr, ok := err.(Error)
+		if !ok {
+			t.Fatalf("expected libcontainer error type but received %v", err)
+		}
+		if lerr.Code() != InvalidIdFormat {
+			t.Fatalf("expected error code %s for id %q but received %s", InvalidIdFormat, id, lerr.Code())
+		}
+	}
+	if _, err := factory.Create(strings.Repeat("a", maxIDLength), config); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestFactoryLoadNotExists(t *testing.T) {
 	root, rerr := newTestRoot()
 	if rerr != nil {
@@ -205,3 +399,76 @@ type unserializableHook struct{}
 func (unserializableHook) Run(configs.HookState) error {
 	return nil
 }
+
+func TestFactoryLoadKeepsUnknownStateFields(t *testing.T) {
+	root, err := newTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	id := "1"
+	if err := os.Mkdir(filepath.Join(root, id), 0700); err != nil {
+		t.Fatal(err)
+	}
+	started, err := system.GetProcessStartTime(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fmt.Sprintf(`{"schema_version":1,"id":"1","init_process_pid":%d,"init_process_start":%q,"config":{"rootfs":"/mycontainer/root"},"network_driver":{"name":"future"}}`, os.Getpid(), started)
+	if err := ioutil.WriteFile(filepath.Join(root, id, stateFilename), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	factory, err := New(root, Cgroupfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container, err := factory.Load(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := container.(*linuxContainer)
+	state, err := c.currentState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.saveState(state); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := ioutil.ReadFile(filepath.Join(root, id, stateFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(saved, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fields["network_driver"], map[string]interface{}{"name": "future"}) {
+		t.Fatalf("expected unknown field to be kept but saved state is %s", saved)
+	}
+	if fields["schema_version"] != float64(stateSchemaVersion) {
+		t.Fatalf("expected schema version %d but saved state is %s", stateSchemaVersion, saved)
+	}
+}
+
+func TestFactoryLoadNewerStateSchema(t *testing.T) {
+	root, err := newTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	id := "1"
+	if err := os.Mkdir(filepath.Join(root, id), 0700); err != nil {
+		t.Fatal(err)
+	}
+	state := &State{BaseState: BaseState{SchemaVersion: stateSchemaVersion + 1}}
+	if err := marshal(filepath.Join(root, id, stateFilename), state); err != nil {
+		t.Fatal(err)
+	}
+	factory, err := New(root, Cgroupfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := factory.Load(id); err == nil {
+		t.Fatal("expected error loading state with a newer schema version")
+	}
+}
@@ -1750,3 +1750,59 @@ func TestTmpfsCopyUp(t *testing.T) {
 		t.Fatalf("/etc/passwd not copied up as expected: %v", outputLs)
 	}
 }
+
+func TestRunStatusTransitions(t *testing.T) {
+	if testing.Short() {
+		return
+	}
+	rootfs, err := newRootfs()
+	ok(t, err)
+	defer remove(rootfs)
+	config := newTemplateConfig(rootfs)
+	container, err := newContainer(config)
+	ok(t, err)
+	defer container.Destroy()
+
+	status, err := container.Status()
+	ok(t, err)
+	if status != libcontainer.Stopped {
+		t.Fatalf("expected container to be stopped before run but it is %s", status)
+	}
+
+	stdinR, stdinW, err := os.Pipe()
+	ok(t, err)
+	process := &libcontainer.Process{
+		Cwd:   "/",
+		Args:  []string{"cat"},
+		Env:   standardEnvironment,
+		Stdin: stdinR,
+	}
+	err = container.Run(process)
+	stdinR.Close()
+	defer stdinW.Close()
+	ok(t, err)
+
+	// Run has waited for the init to exec the user process.
+	status, err = container.Status()
+	ok(t, err)
+	if status != libcontainer.Running {
+		t.Fatalf("expected container to be running after run but it is %s", status)
+	}
+
+	// running another process does not change the container's status.
+	ps := &libcontainer.Process{
+		Cwd:  "/",
+		Args: []string{"true"},
+		Env:  standardEnvironment,
+	}
+	ok(t, container.Run(ps))
+	waitProcess(ps, t)
+	status, err = container.Status()
+	ok(t, err)
+	if status != libcontainer.Running {
+		t.Fatalf("expected container to still be running but it is %s", status)
+	}
+
+	stdinW.Close()
+	waitProcess(process, t)
+}
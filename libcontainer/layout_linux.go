@@ -0,0 +1,102 @@
+// +build linux
+
+package libcontainer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const layoutVersionFilename = ".version"
+
+// layoutVersion is the version of the on-disk layout of a factory's root
+// directory. Any change to where or how container state is stored must bump
+// it and register a migration from the previous version.
+const layoutVersion = 1
+
+// layoutMigrations upgrade a factory root in place. The function stored at
+// index n upgrades a root at version n to version n+1. Migrations must be
+// idempotent as they are rerun if runc is interrupted before the new version
+// is recorded.
+var layoutMigrations = []func(root string) error{
+	// 0 -> 1: roots created before the layout was versioned only differ by
+	// the missing version file.
+	func(string) error { return nil },
+}
+
+// migrateLayout brings the factory root up to the current layout version,
+// failing if the root was written by a newer version of libcontainer.
+func migrateLayout(root string) error {
+	dir, err := os.Open(root)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	// serialize migrations between concurrent runc invocations.
+	if err := syscall.Flock(int(dir.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(dir.Fd()), syscall.LOCK_UN)
+
+	version, err := readLayoutVersion(root)
+	if err != nil {
+		return err
+	}
+	if version == layoutVersion {
+		return nil
+	}
+	if version > layoutVersion {
+		return fmt.Errorf("root %s has layout version %d, this version of libcontainer only supports up to %d", root, version, layoutVersion)
+	}
+	for ; version < layoutVersion; version++ {
+		if err := layoutMigrations[version](root); err != nil {
+			return fmt.Errorf("migrating root %s from layout version %d: %v", root, version, err)
+		}
+	}
+	return writeLayoutVersion(root, layoutVersion)
+}
+
+// readLayoutVersion returns the layout version of root, which is 0 if it
+// was never recorded.
+func readLayoutVersion(root string) (int, error) {
+	data, err := ioutil.ReadFile(filepath.Join(root, layoutVersionFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	path := filepath.Join(root, layoutVersionFilename)
+	version, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid layout version in %s: %v", path, err)
+	}
+	if version < 0 {
+		return 0, fmt.Errorf("invalid layout version in %s: %d", path, version)
+	}
+	return version, nil
+}
+
+func writeLayoutVersion(root string, version int) error {
+	tmp, err := ioutil.TempFile(root, layoutVersionFilename)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = fmt.Fprintf(tmp, "%d\n", version)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(root, layoutVersionFilename))
+}
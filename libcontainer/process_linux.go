@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/configs"
@@ -266,9 +267,12 @@ func (p *initProcess) start() error {
 			p.manager.Destroy()
 		}
 	}()
+	start := time.Now()
 	if err := p.createNetworkInterfaces(); err != nil {
 		return newSystemErrorWithCause(err, "creating network interfaces")
 	}
+	utils.LogTiming("network", start)
+	start = time.Now()
 	if err := p.sendConfig(); err != nil {
 		return newSystemErrorWithCause(err, "sending config to init process")
 	}
@@ -280,6 +284,9 @@ func (p *initProcess) start() error {
 	ierr := parseSync(p.parentPipe, func(sync *syncT) error {
 		switch sync.Type {
 		case procReady:
+			// the init has set up the rootfs and mounts by now.
+			utils.LogTiming("container init", start)
+			start = time.Now()
 			if err := p.manager.Set(p.config.Config); err != nil {
 				return newSystemErrorWithCause(err, "setting cgroup config for ready process")
 			}
@@ -304,12 +311,14 @@ func (p *initProcess) start() error {
 					}
 				}
 			}
+			utils.LogTiming("ready", start)
 			// Sync with child.
 			if err := writeSync(p.parentPipe, procRun); err != nil {
 				return newSystemErrorWithCause(err, "writing syncT 'run'")
 			}
 			sentRun = true
 		case procHooks:
+			start = time.Now()
 			if p.config.Config.Hooks != nil {
 				s := configs.HookState{
 					Version: p.container.config.Version,
@@ -323,6 +332,7 @@ func (p *initProcess) start() error {
 					}
 				}
 			}
+			utils.LogTiming("prestart hooks", start)
 			// Sync with child.
 			if err := writeSync(p.parentPipe, procResume); err != nil {
 				return newSystemErrorWithCause(err, "writing syncT 'resume'")
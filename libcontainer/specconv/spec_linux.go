@@ -165,6 +165,14 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 	if !filepath.IsAbs(rootfsPath) {
 		rootfsPath = filepath.Join(cwd, rootfsPath)
 	}
+	// Resolve any symlinks so that the path stored in the container's state
+	// is the real location of the rootfs. A missing rootfs is left for the
+	// validator to report.
+	if resolved, err := filepath.EvalSymlinks(rootfsPath); err == nil {
+		rootfsPath = resolved
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
 	labels := []string{}
 	for k, v := range spec.Annotations {
 		labels = append(labels, fmt.Sprintf("%s=%s", k, v))
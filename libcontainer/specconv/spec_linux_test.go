@@ -3,8 +3,12 @@
 package specconv
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/configs/validate"
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
@@ -118,3 +122,78 @@ func TestRootlessSpecconvValidate(t *testing.T) {
 		t.Errorf("Expected specconv to produce valid rootless container config: %v", err)
 	}
 }
+
+func newTestBundle(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "specconv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// resolve the temp dir itself, it may live under a symlink
+	bundle, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(bundle, "rootfs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return bundle
+}
+
+func createConfigInBundle(t *testing.T, bundle, root string) *configs.Config {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(bundle); err != nil {
+		t.Fatal(err)
+	}
+	spec := Example()
+	spec.Root.Path = root
+	config, err := CreateLibcontainerConfig(&CreateOpts{
+		CgroupName: "ContainerID",
+		Spec:       spec,
+	})
+	if err != nil {
+		t.Fatalf("Couldn't create libcontainer config: %v", err)
+	}
+	return config
+}
+
+func TestRootfsRelativePath(t *testing.T) {
+	bundle := newTestBundle(t)
+	defer os.RemoveAll(bundle)
+
+	config := createConfigInBundle(t, bundle, "rootfs")
+	if expected := filepath.Join(bundle, "rootfs"); config.Rootfs != expected {
+		t.Errorf("Wrong rootfs, expected '%s' got '%s'", expected, config.Rootfs)
+	}
+}
+
+func TestRootfsAbsolutePath(t *testing.T) {
+	bundle := newTestBundle(t)
+	defer os.RemoveAll(bundle)
+
+	expected := filepath.Join(bundle, "rootfs")
+	config := createConfigInBundle(t, "/", expected)
+	if config.Rootfs != expected {
+		t.Errorf("Wrong rootfs, expected '%s' got '%s'", expected, config.Rootfs)
+	}
+}
+
+func TestRootfsSymlinkResolved(t *testing.T) {
+	bundle := newTestBundle(t)
+	defer os.RemoveAll(bundle)
+	if err := os.Symlink("rootfs", filepath.Join(bundle, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	config := createConfigInBundle(t, bundle, "link")
+	if expected := filepath.Join(bundle, "rootfs"); config.Rootfs != expected {
+		t.Errorf("Wrong rootfs, expected '%s' got '%s'", expected, config.Rootfs)
+	}
+	validator := validate.New()
+	if err := validator.Validate(config); err != nil {
+		t.Errorf("Expected resolved rootfs to pass validation: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package system
 
 import (
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
 	"strconv"
@@ -39,5 +40,31 @@ func parseStartTime(stat string) (string, error) {
 	// get parts after last `)`:
 	s := strings.Split(stat, ")")
 	parts := strings.Split(strings.TrimSpace(s[len(s)-1]), " ")
+	if len(s) < 2 || len(parts) < 22-2 {
+		return "", fmt.Errorf("invalid stat data: %q", stat)
+	}
 	return parts[22-3], nil // starts at 3 (after the filename pos `2`)
 }
+
+// GetParentPid returns the pid of the parent of the given process as found
+// in /proc.
+func GetParentPid(pid int) (int, error) {
+	data, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, err
+	}
+	return parseParentPid(string(data))
+}
+
+func parseParentPid(stat string) (int, error) {
+	// the ppid is located at pos 4, see parseStartTime for the format.
+	i := strings.LastIndex(stat, ")")
+	if i < 0 {
+		return 0, fmt.Errorf("invalid stat data: %q", stat)
+	}
+	parts := strings.Fields(stat[i+1:])
+	if len(parts) < 2 {
+		return 0, fmt.Errorf("invalid stat data: %q", stat)
+	}
+	return strconv.Atoi(parts[4-3])
+}
@@ -0,0 +1,18 @@
+// +build go1.18
+
+package system
+
+import "testing"
+
+func FuzzParseStat(f *testing.F) {
+	f.Add("4902 (gunicorn: maste) S 4885 4902 4902 0 -1 4194560 29683 29929 61 83 78 16 96 17 20 0 1 0 9126532 52965376 1903 18446744073709551615 4194304")
+	f.Add("24767 (irq/44-mei) me) S 2 0 0 0 -1 2129984 0 0 0 0 0 0 0 0 -51 0 1 0 8722075 0 0")
+	f.Add("1 (a")
+	f.Add(")")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, stat string) {
+		// malformed /proc data must be reported, not panic.
+		parseStartTime(stat)
+		parseParentPid(stat)
+	})
+}
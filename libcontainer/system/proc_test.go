@@ -18,3 +18,23 @@ func TestParseStartTime(t *testing.T) {
 		}
 	}
 }
+
+func TestParseParentPid(t *testing.T) {
+	data := map[string]int{
+		"4902 (gunicorn: maste) S 4885 4902 4902 0 -1 4194560 29683 29929 61 83 78 16 96 17 20 0 1 0 9126532 52965376 1903": 4885,
+		"9534 (cat) R 9323 9534 9323 34828 9534 4194304 95 0 0 0 0 0 0 0 20 0 1 0 9214966 7626752 168":                      9323,
+		"24767 (irq/44-mei) me) S 2 0 0 0 -1 2129984 0 0 0 0 0 0 0 0 -51 0 1 0 8722075 0 0":                                 2,
+	}
+	for line, ppid := range data {
+		p, err := parseParentPid(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ppid != p {
+			t.Fatalf("expected ppid %d but received %d", ppid, p)
+		}
+	}
+	if _, err := parseParentPid("1 (init"); err == nil {
+		t.Fatal("expected error parsing truncated stat data")
+	}
+}
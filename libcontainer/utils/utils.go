@@ -9,13 +9,27 @@ import (
 	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 	"unsafe"
+
+	"github.com/Sirupsen/logrus"
 )
 
 const (
 	exitSignalOffset = 128
 )
 
+// LogTiming writes how long a phase of the current operation took, since
+// start, to the debug log so that slow operations can be broken down with
+// --debug. The duration is logged in seconds as a number so that it can be
+// aggregated by tools reading the JSON log format.
+func LogTiming(phase string, start time.Time) {
+	logrus.WithFields(logrus.Fields{
+		"phase":    phase,
+		"duration": time.Since(start).Seconds(),
+	}).Debug("timing")
+}
+
 // GenerateRandomName returns a new name joined with a prefix.  This size
 // specified is used to truncate the randomly generated value
 func GenerateRandomName(prefix string, size int) (string, error) {
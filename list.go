@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"syscall"
 	"text/tabwriter"
 	"time"
@@ -42,6 +43,10 @@ type containerState struct {
 	Annotations map[string]string `json:"annotations,omitempty"`
 	// The owner of the state directory (the owner of the container).
 	Owner string `json:"owner"`
+	// Command is the runc command line that created the container.
+	Command []string `json:"command,omitempty"`
+	// RuncVersion is the version of runc that created the container.
+	RuncVersion string `json:"runcVersion,omitempty"`
 }
 
 var listCommand = cli.Command{
@@ -89,15 +94,16 @@ To list containers created using a non-default value for "--root":
 		switch context.String("format") {
 		case "table":
 			w := tabwriter.NewWriter(os.Stdout, 12, 1, 3, ' ', 0)
-			fmt.Fprint(w, "ID\tPID\tSTATUS\tBUNDLE\tCREATED\tOWNER\n")
+			fmt.Fprint(w, "ID\tPID\tSTATUS\tBUNDLE\tCREATED\tOWNER\tVERSION\n")
 			for _, item := range s {
-				fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n",
+				fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
 					item.ID,
 					item.InitProcessPid,
 					item.Status,
 					item.Bundle,
 					item.Created.Format(time.RFC3339Nano),
-					item.Owner)
+					item.Owner,
+					item.RuncVersion)
 			}
 			if err := w.Flush(); err != nil {
 				return err
@@ -133,10 +139,7 @@ func getContainers(context *cli.Context) ([]containerState, error) {
 		if item.IsDir() {
 			// This cast is safe on Linux.
 			stat := item.Sys().(*syscall.Stat_t)
-			owner, err := user.LookupUid(int(stat.Uid))
-			if err != nil {
-				owner.Name = string(stat.Uid)
-			}
+			owner := uidName(int(stat.Uid))
 
 			container, err := factory.Load(item.Name())
 			if err != nil {
@@ -158,7 +161,7 @@ func getContainers(context *cli.Context) ([]containerState, error) {
 				pid = 0
 			}
 			bundle, annotations := utils.Annotations(state.Config.Labels)
-			s = append(s, containerState{
+			cs := containerState{
 				Version:        state.BaseState.Config.Version,
 				ID:             state.BaseState.ID,
 				InitProcessPid: pid,
@@ -167,9 +170,52 @@ func getContainers(context *cli.Context) ([]containerState, error) {
 				Rootfs:         state.BaseState.Config.Rootfs,
 				Created:        state.BaseState.Created,
 				Annotations:    annotations,
-				Owner:          owner.Name,
-			})
+				Owner:          owner,
+			}
+			if err := setCreator(context, &cs); err != nil {
+				fmt.Fprintf(os.Stderr, "creator of %s: %v\n", item.Name(), err)
+			}
+			s = append(s, cs)
 		}
 	}
 	return s, nil
 }
+
+// setCreator fills in the creator details of cs. The owner is the user that
+// created the container, when recorded, rather than the owner of its state
+// directory, which is the container's root user.
+func setCreator(context *cli.Context, cs *containerState) error {
+	c, err := readCreator(context, cs.ID)
+	if err != nil || c == nil {
+		return err
+	}
+	cs.Owner = uidName(c.UID)
+	cs.Command = c.Command
+	cs.RuncVersion = c.Version
+	return nil
+}
+
+// uidName returns the name of the user with the given uid, or the uid itself
+// if it has no name.
+func uidName(uid int) string {
+	u, err := user.LookupUid(uid)
+	if err != nil {
+		return strconv.Itoa(uid)
+	}
+	return u.Name
+}
+
+// stateDirOwner returns the name of the owner of the container's state
+// directory, which is the container's root user.
+func stateDirOwner(context *cli.Context, id string) (string, error) {
+	root, err := filepath.Abs(context.GlobalString("root"))
+	if err != nil {
+		return "", err
+	}
+	fi, err := os.Stat(filepath.Join(root, id))
+	if err != nil {
+		return "", err
+	}
+	// This cast is safe on Linux.
+	return uidName(int(fi.Sys().(*syscall.Stat_t).Uid)), nil
+}
@@ -89,6 +89,18 @@ func main() {
 			Name:  "systemd-cgroup",
 			Usage: "enable systemd cgroup support, expects cgroupsPath to be of form \"slice:prefix:name\" for e.g. \"system.slice:runc:434234\"",
 		},
+		cli.BoolFlag{
+			Name:  "unsafe-root",
+			Usage: "only warn, instead of failing, when the root directory is not owned by the current user or is writable by others",
+		},
+		cli.StringFlag{
+			Name:  "umask",
+			Usage: "octal umask of runc while setting up containers, instead of the one runc was started with",
+		},
+		cli.Uint64Flag{
+			Name:  "nofile-limit",
+			Usage: "raise the RLIMIT_NOFILE of runc while setting up containers, the container's init keeps the raised limit unless its config sets RLIMIT_NOFILE",
+		},
 	}
 	app.Commands = []cli.Command{
 		checkpointCommand,
@@ -104,6 +116,7 @@ func main() {
 		restoreCommand,
 		resumeCommand,
 		runCommand,
+		shutdownAllCommand,
 		specCommand,
 		startCommand,
 		stateCommand,
@@ -5,11 +5,17 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/opencontainers/runc/libcontainer/system"
 	"github.com/urfave/cli"
 )
 
@@ -23,6 +29,14 @@ var psCommand = cli.Command{
 			Value: "table",
 			Usage: `select one of: ` + formatOptions,
 		},
+		cli.BoolFlag{
+			Name:  "history",
+			Usage: "display the processes previously started with exec instead",
+		},
+		cli.BoolFlag{
+			Name:  "tree",
+			Usage: "display the processes as a tree of parent and child processes",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, minArgs); err != nil {
@@ -38,11 +52,19 @@ var psCommand = cli.Command{
 			return err
 		}
 
+		if context.Bool("history") {
+			return printExecHistory(context, container.ID())
+		}
+
 		pids, err := container.Processes()
 		if err != nil {
 			return err
 		}
 
+		if context.Bool("tree") {
+			return printProcessTree(context, pids)
+		}
+
 		switch context.String("format") {
 		case "table":
 		case "json":
@@ -66,35 +88,51 @@ var psCommand = cli.Command{
 			return fmt.Errorf("%s: %s", err, output)
 		}
 
-		lines := strings.Split(string(output), "\n")
-		pidIndex, err := getPidIndex(lines[0])
+		lines, err := filterPsOutput(string(output), pids)
 		if err != nil {
 			return err
 		}
-
-		fmt.Println(lines[0])
-		for _, line := range lines[1:] {
-			if len(line) == 0 {
-				continue
-			}
-			fields := strings.Fields(line)
-			p, err := strconv.Atoi(fields[pidIndex])
-			if err != nil {
-				return fmt.Errorf("unexpected pid '%s': %s", fields[pidIndex], err)
-			}
-
-			for _, pid := range pids {
-				if pid == p {
-					fmt.Println(line)
-					break
-				}
-			}
+		for _, line := range lines {
+			fmt.Println(line)
 		}
 		return nil
 	},
 	SkipArgReorder: true,
 }
 
+// filterPsOutput returns the header of the ps output followed by the lines
+// of the processes in pids.
+func filterPsOutput(output string, pids []int) ([]string, error) {
+	lines := strings.Split(output, "\n")
+	pidIndex, err := getPidIndex(lines[0])
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []string{lines[0]}
+	for _, line := range lines[1:] {
+		if len(line) == 0 {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) <= pidIndex {
+			return nil, fmt.Errorf("unexpected ps output line %q: missing PID field", line)
+		}
+		p, err := strconv.Atoi(fields[pidIndex])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected pid '%s': %s", fields[pidIndex], err)
+		}
+
+		for _, pid := range pids {
+			if pid == p {
+				filtered = append(filtered, line)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
 func getPidIndex(title string) (int, error) {
 	titles := strings.Fields(title)
 
@@ -107,3 +145,152 @@ func getPidIndex(title string) (int, error) {
 
 	return pidIndex, fmt.Errorf("couldn't find PID field in ps output")
 }
+
+func printExecHistory(context *cli.Context, id string) error {
+	path, err := execHistoryPath(context, id)
+	if err != nil {
+		return err
+	}
+	records, err := readExecHistory(path)
+	if err != nil {
+		return err
+	}
+	switch context.String("format") {
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 12, 1, 3, ' ', 0)
+		fmt.Fprint(w, "STARTED\tEXITED\tSTATUS\tUSER\tCOMMAND\n")
+		for _, r := range records {
+			exited, status := "-", "-"
+			if r.Exited != nil {
+				exited = r.Exited.Format(time.RFC3339Nano)
+			}
+			if r.ExitStatus != nil {
+				status = strconv.Itoa(*r.ExitStatus)
+			}
+			if r.Error != "" {
+				status = "error"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				r.Started.Format(time.RFC3339Nano),
+				exited,
+				status,
+				r.User,
+				strings.Join(r.Args, " "))
+		}
+		return w.Flush()
+	case "json":
+		if records == nil {
+			records = []execRecord{}
+		}
+		return json.NewEncoder(os.Stdout).Encode(records)
+	default:
+		return fmt.Errorf("invalid format option")
+	}
+}
+
+// processNode is a process of the container along with the processes of the
+// container it started.
+type processNode struct {
+	Pid      int            `json:"pid"`
+	Command  string         `json:"command"`
+	Children []*processNode `json:"children,omitempty"`
+}
+
+// buildProcessTree arranges the container's processes by their parent pid.
+// Processes whose parent is not in the container, such as the init, are
+// returned as roots. Processes which exit while the tree is built are left
+// out.
+func buildProcessTree(pids []int) ([]*processNode, error) {
+	var (
+		nodes   = make(map[int]*processNode)
+		parents = make(map[int]int)
+	)
+	for _, pid := range pids {
+		ppid, err := system.GetParentPid(pid)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		nodes[pid] = &processNode{
+			Pid:     pid,
+			Command: processCommand(pid),
+		}
+		parents[pid] = ppid
+	}
+	var roots []*processNode
+	for _, pid := range pids {
+		node, ok := nodes[pid]
+		if !ok {
+			continue
+		}
+		if parent, ok := nodes[parents[pid]]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	sortProcessNodes(roots)
+	return roots, nil
+}
+
+type byPid []*processNode
+
+func (p byPid) Len() int           { return len(p) }
+func (p byPid) Less(i, j int) bool { return p[i].Pid < p[j].Pid }
+func (p byPid) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func sortProcessNodes(nodes []*processNode) {
+	sort.Sort(byPid(nodes))
+	for _, n := range nodes {
+		sortProcessNodes(n.Children)
+	}
+}
+
+// processCommand returns the command line of pid, or its name in brackets
+// for processes without one such as zombies.
+func processCommand(pid int) string {
+	data, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline"))
+	if err == nil && len(data) > 0 {
+		return strings.TrimSpace(strings.Replace(string(data), "\x00", " ", -1))
+	}
+	data, err = ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return "?"
+	}
+	return "[" + strings.TrimSpace(string(data)) + "]"
+}
+
+func printProcessTree(context *cli.Context, pids []int) error {
+	roots, err := buildProcessTree(pids)
+	if err != nil {
+		return err
+	}
+	switch context.String("format") {
+	case "table":
+		for _, root := range roots {
+			fmt.Printf("%d %s\n", root.Pid, root.Command)
+			printProcessChildren(root, "")
+		}
+		return nil
+	case "json":
+		if roots == nil {
+			roots = []*processNode{}
+		}
+		return json.NewEncoder(os.Stdout).Encode(roots)
+	default:
+		return fmt.Errorf("invalid format option")
+	}
+}
+
+func printProcessChildren(node *processNode, prefix string) {
+	for i, child := range node.Children {
+		branch, indent := "|-- ", "|   "
+		if i == len(node.Children)-1 {
+			branch, indent = "`-- ", "    "
+		}
+		fmt.Printf("%s%s%d %s\n", prefix, branch, child.Pid, child.Command)
+		printProcessChildren(child, prefix+indent)
+	}
+}
@@ -0,0 +1,18 @@
+// +build linux,go1.18
+
+package main
+
+import "testing"
+
+func FuzzPsOutput(f *testing.F) {
+	f.Add("UID        PID  PPID  C STIME TTY          TIME CMD\nroot         1     0  0 10:00 ?        00:00:01 sh\n")
+	f.Add("  PID TTY          TIME CMD\n    1 ?        00:00:00 sh\n")
+	f.Add("UID PID CMD\n   \n")
+	f.Add("UID PID CMD\nroot\n")
+	f.Add("PID\nnot-a-pid\n")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, output string) {
+		// unexpected ps output must be reported, not panic.
+		filterPsOutput(output, []int{1})
+	})
+}
@@ -97,13 +97,13 @@ using the runc checkpoint command.`,
 			return err
 		}
 		options := criuOptions(context)
-		status, err := startContainer(context, spec, CT_ACT_RESTORE, options)
+		result, err := startContainer(context, spec, CT_ACT_RESTORE, options)
 		if err != nil {
 			return err
 		}
 		// exit with the container's exit status so any external supervisor is
 		// notified of the exit with the correct exit status.
-		os.Exit(status)
+		os.Exit(result.exitCode())
 		return nil
 	},
 }
@@ -24,7 +24,23 @@ filesystem.
 The specification file includes an args parameter. The args parameter is used
 to specify command(s) that get run when the container is started. To change the
 command(s) that get executed on start, edit the args parameter of the spec. See
-"runc spec --help" for more explanation.`,
+"runc spec --help" for more explanation.
+
+The process environment is built only from the spec and any --env flags. For
+debugging, host environment variables can be passed through by listing their
+names, separated by commas, in the "org.opencontainers.runc.host-env" annotation.
+
+The "org.opencontainers.runc.env-file" annotation names a file, relative to the
+bundle, of KEY=VALUE lines that are merged over the spec's environment before
+any --env flags. When it is set, references of the form ${VAR} in the process
+args are replaced with the value of VAR from the resulting environment.
+References to unset variables are left as they are and $${VAR} is replaced by
+a literal ${VAR}.
+
+The "org.opencontainers.runc.init" annotation names an init binary on the host,
+such as tini, that is mounted read-only at /dev/init in the container and runs
+the process args as "/dev/init -- <args>", reaping zombies and forwarding
+signals for processes that do not do so themselves.`,
 	Flags: []cli.Flag{
 		cli.StringFlag{
 			Name:  "bundle, b",
@@ -57,6 +73,14 @@ command(s) that get executed on start, edit the args parameter of the spec. See
 			Name:  "no-new-keyring",
 			Usage: "do not create a new session keyring for the container.  This will cause the container to inherit the calling processes session key",
 		},
+		cli.StringFlag{
+			Name:  "cwd",
+			Usage: "override the current working directory of the process from the spec",
+		},
+		cli.StringSliceFlag{
+			Name:  "env, e",
+			Usage: "set environment variables, overriding those in the spec",
+		},
 		cli.IntFlag{
 			Name:  "preserve-fds",
 			Usage: "Pass N additional file descriptors to the container (stdio + $LISTEN_FDS + N in total)",
@@ -73,11 +97,11 @@ command(s) that get executed on start, edit the args parameter of the spec. See
 		if err != nil {
 			return err
 		}
-		status, err := startContainer(context, spec, CT_ACT_RUN, nil)
+		result, err := startContainer(context, spec, CT_ACT_RUN, nil)
 		if err == nil {
 			// exit with the container's exit status so any external supervisor is
 			// notified of the exit with the correct exit status.
-			os.Exit(status)
+			os.Exit(result.exitCode())
 		}
 		return err
 	},
@@ -0,0 +1,209 @@
+// +build linux
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/urfave/cli"
+)
+
+var shutdownAllCommand = cli.Command{
+	Name:  "shutdown-all",
+	Usage: "stop all containers under the root, dependents first",
+	ArgsUsage: `
+
+Where the root is specified via the global option "--root"
+(default: "/run/runc").`,
+	Description: `The shutdown-all command stops every container under the root, for example
+when the host is shutting down.
+
+A container is stopped before the containers listed in its
+"` + dependsOnAnnotation + `" annotation, containers that do not depend on
+each other are stopped concurrently. Each container is sent SIGTERM and, if
+it has not stopped within the timeout, SIGKILL. Paused containers are resumed
+first so that they can handle the signal.`,
+	Flags: []cli.Flag{
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Value: 10 * time.Second,
+			Usage: "how long to wait for each container to stop before killing it",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 0, exactArgs); err != nil {
+			return err
+		}
+		containers, err := loadRunningContainers(context)
+		if err != nil {
+			return err
+		}
+		var failed []string
+		for len(containers) > 0 {
+			wave := nextShutdownWave(containers)
+			for _, err := range stopContainers(wave, context.Duration("timeout")) {
+				failed = append(failed, err.Error())
+			}
+			for _, container := range wave {
+				delete(containers, container.ID())
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("failed to stop containers: %s", strings.Join(failed, "; "))
+		}
+		return nil
+	},
+}
+
+// loadRunningContainers returns the containers under the root which have
+// not stopped, keyed by id.
+func loadRunningContainers(context *cli.Context) (map[string]libcontainer.Container, error) {
+	factory, err := loadFactory(context)
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.Abs(context.GlobalString("root"))
+	if err != nil {
+		return nil, err
+	}
+	list, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	containers := make(map[string]libcontainer.Container)
+	for _, item := range list {
+		if !item.IsDir() {
+			continue
+		}
+		container, err := factory.Load(item.Name())
+		if err != nil {
+			logrus.Warnf("load container %s: %v", item.Name(), err)
+			continue
+		}
+		status, err := container.Status()
+		if err != nil {
+			logrus.Warnf("status for %s: %v", item.Name(), err)
+			continue
+		}
+		if status != libcontainer.Stopped {
+			containers[container.ID()] = container
+		}
+	}
+	return containers, nil
+}
+
+// nextShutdownWave returns the containers that no other remaining container
+// depends on, in id order. If the dependencies form a cycle, all remaining
+// containers are returned so that shutdown still makes progress.
+func nextShutdownWave(containers map[string]libcontainer.Container) []libcontainer.Container {
+	needed := make(map[string]bool)
+	for _, container := range containers {
+		for _, id := range containerDependencies(container) {
+			needed[id] = true
+		}
+	}
+	var ids []string
+	for id := range containers {
+		if !needed[id] {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		logrus.Warn("containers depend on each other in a cycle, stopping them all at once")
+		for id := range containers {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	wave := make([]libcontainer.Container, len(ids))
+	for i, id := range ids {
+		wave[i] = containers[id]
+	}
+	return wave
+}
+
+// stopContainers stops the containers concurrently and returns the errors
+// in the same order as the containers.
+func stopContainers(containers []libcontainer.Container, timeout time.Duration) []error {
+	errs := make([]error, len(containers))
+	var wg sync.WaitGroup
+	for i, container := range containers {
+		wg.Add(1)
+		go func(i int, container libcontainer.Container) {
+			defer wg.Done()
+			if err := stopContainer(container, timeout); err != nil {
+				errs[i] = fmt.Errorf("%s: %v", container.ID(), err)
+			}
+		}(i, container)
+	}
+	wg.Wait()
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return failed
+}
+
+func stopContainer(container libcontainer.Container, timeout time.Duration) error {
+	status, err := container.Status()
+	if err != nil {
+		return err
+	}
+	if status == libcontainer.Paused {
+		if err := container.Resume(); err != nil {
+			return err
+		}
+	}
+	for _, s := range []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL} {
+		if err := container.Signal(s, false); err != nil {
+			if stopped, serr := isContainerStopped(container); serr == nil && stopped {
+				return nil
+			}
+			return err
+		}
+		stopped, err := waitForStop(container, timeout)
+		if err != nil {
+			return err
+		}
+		if stopped {
+			return nil
+		}
+		logrus.Warnf("container %s did not stop within %s after %s", container.ID(), timeout, s)
+	}
+	return fmt.Errorf("container did not stop")
+}
+
+// waitForStop polls the container's status until it has stopped or the
+// timeout expired.
+func waitForStop(container libcontainer.Container, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		stopped, err := isContainerStopped(container)
+		if err != nil || stopped {
+			return stopped, err
+		}
+		if time.Now().After(deadline) {
+			return false, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func isContainerStopped(container libcontainer.Container) (bool, error) {
+	status, err := container.Status()
+	if err != nil {
+		return false, err
+	}
+	return status == libcontainer.Stopped, nil
+}
@@ -3,11 +3,18 @@ package main
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/utils"
 	"github.com/urfave/cli"
 )
 
+// dependsOnAnnotation names a comma separated list of containers, under the
+// same root, which must be running before the container is started.
+const dependsOnAnnotation = "org.opencontainers.runc.depends-on"
+
 var startCommand = cli.Command{
 	Name:  "start",
 	Usage: "executes the user defined process in a created container",
@@ -16,7 +23,18 @@ var startCommand = cli.Command{
 Where "<container-id>" is your name for the instance of the container that you
 are starting. The name you provide for the container instance must be unique on
 your host.`,
-	Description: `The start command executes the user defined process in a created container.`,
+	Description: `The start command executes the user defined process in a created container.
+
+If the container has the "` + dependsOnAnnotation + `" annotation, a comma
+separated list of container ids, start waits for each of those containers to
+be running before executing the user defined process.`,
+	Flags: []cli.Flag{
+		cli.DurationFlag{
+			Name:  "dependency-timeout",
+			Value: time.Minute,
+			Usage: "how long to wait for the containers the container depends on to be running",
+		},
+	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, exactArgs); err != nil {
 			return err
@@ -31,6 +49,9 @@ your host.`,
 		}
 		switch status {
 		case libcontainer.Created:
+			if err := waitForDependencies(context, container); err != nil {
+				return err
+			}
 			return container.Exec()
 		case libcontainer.Stopped:
 			return errors.New("cannot start a container that has stopped")
@@ -41,3 +62,76 @@ your host.`,
 		}
 	},
 }
+
+// waitForDependencies blocks until all the containers listed in the
+// container's depends-on annotation are running.
+func waitForDependencies(context *cli.Context, container libcontainer.Container) error {
+	dependencies := containerDependencies(container)
+	if len(dependencies) == 0 {
+		return nil
+	}
+	factory, err := loadFactory(context)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(context.Duration("dependency-timeout"))
+	for _, id := range dependencies {
+		if err := waitForDependency(factory, container.ID(), id, deadline); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForDependency blocks until the container id, which the container self
+// depends on, is running. A container which does not exist yet or has not been
+// started yet is waited for, one which has stopped or which is created and
+// waits for self in turn is an error as it will never be running.
+func waitForDependency(factory libcontainer.Factory, self, id string, deadline time.Time) error {
+	for {
+		container, err := factory.Load(id)
+		if err != nil {
+			if lerr, ok := err.(libcontainer.Error); !ok || lerr.Code() != libcontainer.ContainerNotExists {
+				return err
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timed out waiting for container %s to be running, it does not exist", id)
+			}
+		} else {
+			status, err := container.Status()
+			if err != nil {
+				return err
+			}
+			switch status {
+			case libcontainer.Running:
+				return nil
+			case libcontainer.Stopped:
+				return fmt.Errorf("container %s that this container depends on has stopped", id)
+			case libcontainer.Created:
+				for _, dep := range containerDependencies(container) {
+					if dep == self {
+						return fmt.Errorf("container %s and this container depend on each other", id)
+					}
+				}
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timed out waiting for container %s to be running, it is %s", id, status)
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// containerDependencies returns the ids listed in the container's depends-on
+// annotation, leaving out the container itself.
+func containerDependencies(container libcontainer.Container) []string {
+	_, annotations := utils.Annotations(container.Config().Labels)
+	var ids []string
+	for _, id := range strings.Split(annotations[dependsOnAnnotation], ",") {
+		id = strings.TrimSpace(id)
+		if id != "" && id != container.ID() {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
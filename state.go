@@ -4,6 +4,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 
 	"github.com/opencontainers/runc/libcontainer"
@@ -50,6 +51,15 @@ instance of a container.`,
 			Created:        state.BaseState.Created,
 			Annotations:    annotations,
 		}
+		if owner, err := stateDirOwner(context, cs.ID); err != nil {
+			fmt.Fprintf(os.Stderr, "owner of %s: %v\n", cs.ID, err)
+		} else {
+			cs.Owner = owner
+		}
+		// like list, report what is known if the creator cannot be read.
+		if err := setCreator(context, &cs); err != nil {
+			fmt.Fprintf(os.Stderr, "creator of %s: %v\n", cs.ID, err)
+		}
 		data, err := json.MarshalIndent(cs, "", "  ")
 		if err != nil {
 			return err
@@ -87,3 +87,58 @@ function teardown() {
 
   testcontainer test_busybox running
 }
+
+@test "runc create with missing executable" {
+  sed -i 's;"sh";"/bin/nonexistent";' config.json
+
+  runc create --console-socket $CONSOLE_SOCKET test_busybox
+  [ "$status" -ne 0 ]
+  [[ "${output}" == *"executable \"/bin/nonexistent\" not found in rootfs"* ]]
+}
+
+@test "runc run with cwd and env overrides" {
+  sed -i 's;"sh";"sh", "-c", "pwd; echo $FOO";' config.json
+
+  runc run --cwd /tmp --env FOO=bar test_busybox
+  [ "$status" -eq 0 ]
+  [[ "${lines[0]}" == *"/tmp"* ]]
+  [[ "${lines[1]}" == *"bar"* ]]
+}
+
+@test "runc run with invalid cwd and env overrides" {
+  runc run --cwd tmp test_busybox
+  [ "$status" -ne 0 ]
+  [[ "${output}" == *'--cwd "tmp": must be an absolute path'* ]]
+
+  runc run --env FOO test_busybox
+  [ "$status" -ne 0 ]
+  [[ "${output}" == *'--env "FOO": expected KEY=VALUE'* ]]
+}
+
+@test "runc run with env file and args templating" {
+  echo "GREETING=hello from env file" > env.list
+  sed -i 's;"sh";"echo", "${GREETING}", "$${GREETING}";' config.json
+  jq '.annotations["org.opencontainers.runc.env-file"] = "env.list"' config.json > config.json.tmp
+  mv config.json.tmp config.json
+
+  runc run test_busybox
+  [ "$status" -eq 0 ]
+  [[ "${output}" == *'hello from env file ${GREETING}'* ]]
+}
+
+@test "runc run with env file outside of the bundle" {
+  jq '.annotations["org.opencontainers.runc.env-file"] = "../../../etc/passwd"' config.json > config.json.tmp
+  mv config.json.tmp config.json
+
+  runc run test_busybox
+  [ "$status" -ne 0 ]
+  [[ "${output}" == *"org.opencontainers.runc.env-file annotation"* ]]
+}
+
+@test "runc run without env file leaves args alone" {
+  sed -i 's;"sh";"sh", "-c", "X=1; echo ${X}";' config.json
+
+  runc run --env X=0 test_busybox
+  [ "$status" -eq 0 ]
+  [[ "${output}" == *"1"* ]]
+}
@@ -112,3 +112,59 @@ function teardown() {
 
   [[ ${output} == "uid=1000 gid=1000" ]]
 }
+
+@test "runc exec history" {
+  # XXX: currently cgroups require root containers.
+  requires root
+
+  # run busybox detached
+  runc run -d --console-socket $CONSOLE_SOCKET test_busybox
+  [ "$status" -eq 0 ]
+
+  runc exec test_busybox echo Hello from exec
+  [ "$status" -eq 0 ]
+
+  runc exec test_busybox sh -c 'exit 3'
+  [ "$status" -eq 3 ]
+
+  runc exec test_busybox /nonexistent
+  [ "$status" -ne 0 ]
+
+  runc ps --history -f json test_busybox
+  [ "$status" -eq 0 ]
+  history="$output"
+
+  [ "$(echo "$history" | jq length)" -eq 3 ]
+
+  [ "$(echo "$history" | jq -r '.[0].args | join(" ")')" = "echo Hello from exec" ]
+  [ "$(echo "$history" | jq -r '.[0].exit_status')" -eq 0 ]
+  [ "$(echo "$history" | jq -r '.[0].error')" = "null" ]
+
+  [ "$(echo "$history" | jq -r '.[1].args | join(" ")')" = "sh -c exit 3" ]
+  [ "$(echo "$history" | jq -r '.[1].exit_status')" -eq 3 ]
+  [ "$(echo "$history" | jq -r '.[1].error')" = "null" ]
+
+  [ "$(echo "$history" | jq -r '.[2].args | join(" ")')" = "/nonexistent" ]
+  [ "$(echo "$history" | jq -r '.[2].exit_status')" = "null" ]
+  [[ "$(echo "$history" | jq -r '.[2].error')" == *"/nonexistent"* ]]
+}
+
+@test "runc exec with host env" {
+  export RUNC_HOST_VAR=from-host RUNC_SPEC_VAR=from-host
+  jq '.annotations["org.opencontainers.runc.host-env"] = "RUNC_HOST_VAR, RUNC_SPEC_VAR" | .process.env += ["RUNC_SPEC_VAR=from-spec"]' config.json > config.json.tmp
+  mv config.json.tmp config.json
+
+  # run busybox detached
+  runc run -d --console-socket $CONSOLE_SOCKET test_busybox
+  [ "$status" -eq 0 ]
+
+  # the run process gets the allowed host variables, but the spec wins.
+  runc exec test_busybox sh -c 'tr "\0" "\n" < /proc/1/environ'
+  [ "$status" -eq 0 ]
+  [[ "${output}" == *"RUNC_HOST_VAR=from-host"* ]]
+  [[ "${output}" == *"RUNC_SPEC_VAR=from-spec"* ]]
+
+  runc exec test_busybox sh -c 'echo $RUNC_HOST_VAR $RUNC_SPEC_VAR'
+  [ "$status" -eq 0 ]
+  [[ "${output}" == *"from-host from-spec"* ]]
+}
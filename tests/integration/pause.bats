@@ -70,3 +70,27 @@ function teardown() {
   runc state test_busybox
   [ "$status" -ne 0 ]
 }
+
+@test "runc exec refused while paused" {
+  # XXX: currently cgroups require root containers.
+  requires root
+
+  # run busybox detached
+  runc run -d --console-socket $CONSOLE_SOCKET test_busybox
+  [ "$status" -eq 0 ]
+
+  runc pause test_busybox
+  [ "$status" -eq 0 ]
+  testcontainer test_busybox paused
+
+  # exec is refused until the container is resumed
+  runc exec test_busybox true
+  [ "$status" -ne 0 ]
+  [[ "${output}" == *"paused"* ]]
+
+  runc resume test_busybox
+  [ "$status" -eq 0 ]
+
+  runc exec test_busybox true
+  [ "$status" -eq 0 ]
+}
@@ -48,3 +48,17 @@ function teardown() {
   ROOT=$HELLO_BUNDLE runc delete test_dotbox
   [ "$status" -eq 0 ]
 }
+
+@test "global --unsafe-root" {
+  UNSAFE_ROOT=$(mktemp -d)
+  chmod 0777 "$UNSAFE_ROOT"
+
+  # a root writable by others is refused by every command.
+  ROOT=$UNSAFE_ROOT runc list
+  [ "$status" -ne 0 ]
+  [[ "${output}" == *"writable by other users"* ]]
+
+  ROOT=$UNSAFE_ROOT runc --unsafe-root list
+  rm -rf "$UNSAFE_ROOT"
+  [ "$status" -eq 0 ]
+}
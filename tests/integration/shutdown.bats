@@ -0,0 +1,43 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+  teardown_running_container test_busybox_dep
+  teardown_busybox
+  setup_busybox
+}
+
+function teardown() {
+  teardown_running_container test_busybox_dep
+  teardown_busybox
+}
+
+@test "runc shutdown-all" {
+  # each container appends its name to a shared file when it is stopped.
+  SHARED=$(mktemp -d)
+  jq --arg shared "$SHARED" '.mounts += [{"source": $shared, "destination": "/shared", "type": "bind", "options": ["rbind"]}]
+    | .process.args = ["sh", "-c", "trap \"echo $NAME >> /shared/order; exit 0\" TERM; while true; do sleep 0.1; done"]' config.json > config.json.tmp
+  mv config.json.tmp config.json
+
+  runc run -d --console-socket $CONSOLE_SOCKET --env NAME=test_busybox test_busybox
+  [ "$status" -eq 0 ]
+
+  jq '.annotations["org.opencontainers.runc.depends-on"] = "test_busybox"' config.json > config.json.tmp
+  mv config.json.tmp config.json
+  runc run -d --console-socket $CONSOLE_SOCKET --env NAME=test_busybox_dep test_busybox_dep
+  [ "$status" -eq 0 ]
+
+  runc shutdown-all --timeout 5s
+  [ "$status" -eq 0 ]
+
+  testcontainer test_busybox stopped
+  testcontainer test_busybox_dep stopped
+
+  # the dependent container has to be stopped first.
+  run cat "$SHARED/order"
+  rm -rf "$SHARED"
+  [ "$status" -eq 0 ]
+  [ "${lines[0]}" = "test_busybox_dep" ]
+  [ "${lines[1]}" = "test_busybox" ]
+}
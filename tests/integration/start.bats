@@ -3,14 +3,22 @@
 load helpers
 
 function setup() {
+  teardown_running_container test_dep
   teardown_busybox
   setup_busybox
 }
 
 function teardown() {
+  teardown_running_container test_dep
   teardown_busybox
 }
 
+function depend_on_test_dep() {
+  # test_dep also gets the annotation, which it ignores as it names itself.
+  jq '.annotations["org.opencontainers.runc.depends-on"] = "test_dep"' config.json > config.json.tmp
+  mv config.json.tmp config.json
+}
+
 @test "runc start" {
   runc create --console-socket $CONSOLE_SOCKET test_busybox
   [ "$status" -eq 0 ]
@@ -29,3 +37,77 @@ function teardown() {
   runc state test_busybox
   [ "$status" -ne 0 ]
 }
+
+@test "runc start waits for dependencies" {
+  depend_on_test_dep
+  runc create --console-socket $CONSOLE_SOCKET test_busybox
+  [ "$status" -eq 0 ]
+
+  # test_dep does not exist yet, so start has to wait for it.
+  __runc start --dependency-timeout 30s test_busybox &
+  start_pid=$!
+  sleep 1
+  testcontainer test_busybox created
+
+  runc run -d --console-socket $CONSOLE_SOCKET test_dep
+  [ "$status" -eq 0 ]
+
+  wait $start_pid
+  testcontainer test_busybox running
+}
+
+@test "runc start with a stopped dependency" {
+  depend_on_test_dep
+  sed -i 's;"sh";"true";' config.json
+  runc run -d --console-socket $CONSOLE_SOCKET test_dep
+  [ "$status" -eq 0 ]
+  retry 10 1 eval "__runc state test_dep | grep -q 'stopped'"
+
+  sed -i 's;"true";"sh";' config.json
+  runc create --console-socket $CONSOLE_SOCKET test_busybox
+  [ "$status" -eq 0 ]
+
+  runc start test_busybox
+  [ "$status" -ne 0 ]
+  [[ "${output}" == *"container test_dep that this container depends on has stopped"* ]]
+  testcontainer test_busybox created
+}
+
+@test "runc start --dependency-timeout" {
+  # XXX: currently cgroups require root containers.
+  requires root
+
+  depend_on_test_dep
+  runc run -d --console-socket $CONSOLE_SOCKET test_dep
+  [ "$status" -eq 0 ]
+  runc pause test_dep
+  [ "$status" -eq 0 ]
+
+  runc create --console-socket $CONSOLE_SOCKET test_busybox
+  [ "$status" -eq 0 ]
+
+  runc start --dependency-timeout 1s test_busybox
+  [ "$status" -ne 0 ]
+  [[ "${output}" == *"timed out waiting for container test_dep to be running, it is paused"* ]]
+  testcontainer test_busybox created
+
+  runc resume test_dep
+  [ "$status" -eq 0 ]
+}
+
+@test "runc start with a dependency cycle" {
+  depend_on_test_dep
+  runc create --console-socket $CONSOLE_SOCKET test_busybox
+  [ "$status" -eq 0 ]
+
+  jq '.annotations["org.opencontainers.runc.depends-on"] = "test_busybox"' config.json > config.json.tmp
+  mv config.json.tmp config.json
+  runc create --console-socket $CONSOLE_SOCKET test_dep
+  [ "$status" -eq 0 ]
+
+  # fails at once instead of waiting out the timeout.
+  runc start --dependency-timeout 30s test_busybox
+  [ "$status" -ne 0 ]
+  [[ "${output}" == *"container test_dep and this container depend on each other"* ]]
+  testcontainer test_busybox created
+}
@@ -64,3 +64,26 @@ function teardown() {
   # test state of busybox is back to running
   testcontainer test_busybox running
 }
+
+@test "state reports the creator" {
+  runc run -d --console-socket $CONSOLE_SOCKET --env SECRET=hunter2 test_busybox
+  [ "$status" -eq 0 ]
+
+  runc state test_busybox
+  [ "$status" -eq 0 ]
+  [[ "$(__runc state test_busybox | jq -r '.command[1:] | join(" ")')" == "--root run --console-socket --detach --env" ]]
+  [[ "$(__runc state test_busybox | jq -r '.owner')" == "$(id -un)" ]]
+  [[ "${output}" != *"hunter2"* ]]
+}
+
+@test "state with a corrupt creator record" {
+  runc run -d --console-socket $CONSOLE_SOCKET test_busybox
+  [ "$status" -eq 0 ]
+
+  echo garbage > "$ROOT/test_busybox/creator.json"
+
+  runc state test_busybox
+  [ "$status" -eq 0 ]
+  [[ "${output}" == *"creator of test_busybox"* ]]
+  [[ "$(__runc state test_busybox 2>/dev/null | jq -r '.id')" == "test_busybox" ]]
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/symlink"
+	"github.com/opencontainers/runc/libcontainer/utils"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/urfave/cli"
 )
@@ -59,13 +65,147 @@ func setupSpec(context *cli.Context) (*specs.Spec, error) {
 			return nil, err
 		}
 	}
+	cwd, env := context.String("cwd"), context.StringSlice("env")
+	if err := checkOverrides(cwd, env); err != nil {
+		return nil, err
+	}
+	start := time.Now()
 	spec, err := loadSpec(specConfig)
 	if err != nil {
 		return nil, err
 	}
+	utils.LogTiming("load spec", start)
+	envFile := spec.Annotations[envFileAnnotation]
+	if envFile != "" {
+		// setupSpec runs in the bundle, which the env file must not escape.
+		bundle, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		path, err := bundlePath(bundle, envFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s annotation: %v", envFileAnnotation, err)
+		}
+		env, err := readEnvFile(path)
+		if err != nil {
+			return nil, err
+		}
+		spec.Process.Env = mergeEnv(spec.Process.Env, env)
+	}
+	// override the cwd and env, if passed
+	if cwd != "" {
+		spec.Process.Cwd = cwd
+	}
+	spec.Process.Env = mergeEnv(spec.Process.Env, env)
+	// args are only templated for bundles that opted in with an env file, so
+	// that ${VAR} in existing shell command lines is left to the shell.
+	if envFile != "" {
+		spec.Process.Args = expandArgs(spec.Process.Args, spec.Process.Env)
+	}
 	return spec, nil
 }
 
+// checkOverrides validates the --cwd and --env flags, which would otherwise
+// only be refused by the container's init.
+func checkOverrides(cwd string, env []string) error {
+	if cwd != "" && !filepath.IsAbs(cwd) {
+		return fmt.Errorf("--cwd %q: must be an absolute path", cwd)
+	}
+	for _, e := range env {
+		if strings.Index(e, "=") <= 0 {
+			return fmt.Errorf("--env %q: expected KEY=VALUE", e)
+		}
+	}
+	return nil
+}
+
+// bundlePath resolves the relative path name inside bundle, following
+// symlinks as if bundle was the root so that the result cannot escape it.
+func bundlePath(bundle, name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("%s must be relative to the bundle", name)
+	}
+	return symlink.FollowSymlinkInScope(filepath.Join(bundle, name), bundle)
+}
+
+// readEnvFile reads KEY=VALUE lines from path. Blank lines and lines starting
+// with # are ignored.
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var (
+		env []string
+		n   int
+		s   = bufio.NewScanner(f)
+	)
+	for s.Scan() {
+		n++
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.Contains(line, "=") {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE but got %q", path, n, line)
+		}
+		env = append(env, line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+var argVarRegexp = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandArgs replaces ${VAR} in args with the value of VAR in env. References
+// to variables that are not set in env are left as they are, and $${VAR} is
+// replaced by a literal ${VAR}.
+func expandArgs(args, env []string) []string {
+	values := make(map[string]string, len(env))
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) == 2 {
+			values[kv[0]] = kv[1]
+		}
+	}
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = argVarRegexp.ReplaceAllStringFunc(arg, func(ref string) string {
+			if strings.HasPrefix(ref, "$$") {
+				return ref[1:]
+			}
+			if value, ok := values[ref[2:len(ref)-1]]; ok {
+				return value
+			}
+			return ref
+		})
+	}
+	return expanded
+}
+
+// mergeEnv returns env with each KEY=VALUE entry of overrides applied, either
+// replacing the existing entry for KEY or appended at the end.
+func mergeEnv(env, overrides []string) []string {
+	for _, o := range overrides {
+		key := strings.SplitN(o, "=", 2)[0]
+		replaced := false
+		for i, e := range env {
+			if strings.SplitN(e, "=", 2)[0] == key {
+				env[i] = o
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			env = append(env, o)
+		}
+	}
+	return env
+}
+
 func revisePidFile(context *cli.Context) error {
 	pidFile := context.String("pid-file")
 	if pidFile == "" {
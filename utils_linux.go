@@ -3,16 +3,21 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/coreos/go-systemd/activation"
+	"github.com/docker/docker/pkg/symlink"
 	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/cgroups/systemd"
 	"github.com/opencontainers/runc/libcontainer/configs"
@@ -24,6 +29,24 @@ import (
 
 var errEmptyID = errors.New("container id cannot be empty")
 
+const creatorFilename = "creator.json"
+
+// hostEnvAnnotation names a comma separated list of host environment
+// variables that are passed through to the container's processes. The
+// environment is otherwise built only from the spec.
+const hostEnvAnnotation = "org.opencontainers.runc.host-env"
+
+// initAnnotation names an init binary on the host, such as tini, that is
+// mounted into the container and run as its init with the process args.
+const initAnnotation = "org.opencontainers.runc.init"
+
+// initPath is where the init binary is mounted in the container.
+const initPath = "/dev/init"
+
+// envFileAnnotation names a file, relative to the bundle, of KEY=VALUE lines
+// that are merged over the spec's process environment.
+const envFileAnnotation = "org.opencontainers.runc.env-file"
+
 var container libcontainer.Container
 
 // loadFactory returns the configured factory instance for execing containers.
@@ -41,7 +64,24 @@ func loadFactory(context *cli.Context) (libcontainer.Factory, error) {
 			return nil, fmt.Errorf("systemd cgroup flag passed, but systemd support for managing cgroups is not available")
 		}
 	}
-	return libcontainer.New(abs, cgroupManager, libcontainer.CriuPath(context.GlobalString("criu")))
+	options := []func(*libcontainer.LinuxFactory) error{
+		cgroupManager,
+		libcontainer.CriuPath(context.GlobalString("criu")),
+	}
+	if context.GlobalBool("unsafe-root") {
+		options = append(options, libcontainer.UnsafeRoot)
+	}
+	if umask := context.GlobalString("umask"); umask != "" {
+		mask, err := strconv.ParseUint(umask, 8, 32)
+		if err != nil || mask > 0777 {
+			return nil, fmt.Errorf("invalid --umask %q: expected an octal mode such as 022", umask)
+		}
+		options = append(options, libcontainer.Umask(int(mask)))
+	}
+	if limit := context.GlobalUint64("nofile-limit"); limit != 0 {
+		options = append(options, libcontainer.NofileLimit(limit))
+	}
+	return libcontainer.New(abs, options...)
 }
 
 // getContainer returns the specified container instance by loading it from state
@@ -104,10 +144,62 @@ func newProcess(p specs.Process) (*libcontainer.Process, error) {
 	return lp, nil
 }
 
+// setupHostEnv prepends the host environment variables allowed by the
+// annotations to the process environment. They are placed first so that
+// a variable also set by the spec keeps the spec's value.
+func setupHostEnv(p *specs.Process, annotations map[string]string) {
+	allowed := annotations[hostEnvAnnotation]
+	if allowed == "" {
+		return
+	}
+	var env []string
+	for _, name := range strings.Split(allowed, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	p.Env = append(env, p.Env...)
+}
+
+// setupInit mounts the init binary named by the annotations into the
+// container and runs the process args through it, so that zombies are reaped
+// and signals forwarded for processes that do not do it themselves.
+func setupInit(spec *specs.Spec) error {
+	path := spec.Annotations[initAnnotation]
+	if path == "" {
+		return nil
+	}
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("%s annotation must be an absolute path: %s", initAnnotation, path)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("init %s is not a regular file", path)
+	}
+	// appended last, so it is mounted over the container's /dev.
+	spec.Mounts = append(spec.Mounts, specs.Mount{
+		Destination: initPath,
+		Type:        "bind",
+		Source:      path,
+		Options:     []string{"bind", "ro"},
+	})
+	spec.Process.Args = append([]string{initPath, "--"}, spec.Process.Args...)
+	return nil
+}
+
 func destroy(container libcontainer.Container) {
+	start := time.Now()
 	if err := container.Destroy(); err != nil {
 		logrus.Error(err)
 	}
+	utils.LogTiming("destroy container", start)
 }
 
 // setupIO modifies the given process config according to the options.
@@ -192,6 +284,7 @@ func isRootless() bool {
 }
 
 func createContainer(context *cli.Context, id string, spec *specs.Spec) (libcontainer.Container, error) {
+	start := time.Now()
 	config, err := specconv.CreateLibcontainerConfig(&specconv.CreateOpts{
 		CgroupName:       id,
 		UseSystemdCgroup: context.GlobalBool("systemd-cgroup"),
@@ -203,12 +296,181 @@ func createContainer(context *cli.Context, id string, spec *specs.Spec) (libcont
 	if err != nil {
 		return nil, err
 	}
+	utils.LogTiming("convert spec", start)
+
+	start = time.Now()
+	if err := checkRootfs(config, &spec.Process); err != nil {
+		return nil, err
+	}
+	utils.LogTiming("check rootfs", start)
 
 	factory, err := loadFactory(context)
 	if err != nil {
 		return nil, err
 	}
-	return factory.Create(id, config)
+	start = time.Now()
+	container, err := factory.Create(id, config)
+	if err != nil {
+		return nil, err
+	}
+	utils.LogTiming("create container", start)
+	if err := writeCreator(context, id); err != nil {
+		logrus.Warnf("recording creator of container %s: %v", id, err)
+	}
+	return container, nil
+}
+
+// creator records who created a container and how, so that list and state
+// can report it on hosts shared by several administrators.
+type creator struct {
+	UID     int      `json:"uid"`
+	Command []string `json:"command"`
+	Version string   `json:"version"`
+}
+
+// creatorCommand returns the runc command line that is running, reduced to
+// the command and the names of the flags that were set. Flag values and
+// arguments are left out as they can hold secrets, such as --env values.
+func creatorCommand(context *cli.Context) []string {
+	command := []string{context.App.Name}
+	command = append(command, setFlagNames(context.App.Flags, context.GlobalIsSet)...)
+	command = append(command, context.Command.Name)
+	return append(command, setFlagNames(context.Command.Flags, context.IsSet)...)
+}
+
+// setFlagNames returns the long names, prefixed with --, of the flags that
+// isSet reports as set under any of their names.
+func setFlagNames(flags []cli.Flag, isSet func(string) bool) []string {
+	var names []string
+	for _, flag := range flags {
+		aliases := strings.Split(flag.GetName(), ",")
+		for _, alias := range aliases {
+			if isSet(strings.TrimSpace(alias)) {
+				names = append(names, "--"+strings.TrimSpace(aliases[0]))
+				break
+			}
+		}
+	}
+	return names
+}
+
+// creatorPath returns the path of the creator record, which is kept in the
+// container's state directory and removed along with it.
+func creatorPath(context *cli.Context, id string) (string, error) {
+	root, err := filepath.Abs(context.GlobalString("root"))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, id, creatorFilename), nil
+}
+
+func writeCreator(context *cli.Context, id string) error {
+	path, err := creatorPath(context, id)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(creator{
+		UID:     os.Getuid(),
+		Command: creatorCommand(context),
+		Version: version,
+	})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// readCreator returns the creator record of a container, or nil if none was
+// recorded because the container was created by an older runc.
+func readCreator(context *cli.Context, id string) (*creator, error) {
+	path, err := creatorPath(context, id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var c creator
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	return &c, nil
+}
+
+// checkRootfs performs sanity checks on the container's rootfs before it is
+// created so that obvious mistakes are reported by create rather than by the
+// container's init failing to exec later on.
+func checkRootfs(config *configs.Config, p *specs.Process) error {
+	entries, err := ioutil.ReadDir(config.Rootfs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("rootfs (%s) does not exist", config.Rootfs)
+		}
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("rootfs (%s) is empty, check the bundle's root.path", config.Rootfs)
+	}
+	if len(p.Args) == 0 || !filepath.IsAbs(p.Args[0]) {
+		return nil
+	}
+	// the binary may be provided by one of the container's mounts.
+	if inMounts(config.Mounts, p.Args[0]) {
+		return nil
+	}
+	path, err := symlink.FollowSymlinkInScope(filepath.Join(config.Rootfs, p.Args[0]), config.Rootfs)
+	if err != nil {
+		return err
+	}
+	// symlinks in the rootfs may also point into a mount, e.g. /bin/sh
+	// linking to /usr/bin/busybox with /usr mounted into the container.
+	rel, err := filepath.Rel(config.Rootfs, path)
+	if err != nil {
+		return err
+	}
+	if inMounts(config.Mounts, filepath.Join("/", rel)) {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("executable %q not found in rootfs (%s)", p.Args[0], config.Rootfs)
+		}
+		return err
+	}
+	return nil
+}
+
+// inMounts reports whether path inside the container is at or below the
+// destination of one of mounts.
+func inMounts(mounts []*configs.Mount, path string) bool {
+	for _, m := range mounts {
+		dest := filepath.Clean(m.Destination)
+		if path == dest || strings.HasPrefix(path, dest+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// runResult is the outcome of starting a process with a runner.
+type runResult struct {
+	// Detached is set when runc returned without waiting for the process.
+	Detached bool
+	// Status is the exit status of the process if runc waited for it.
+	Status int
+}
+
+// exitCode returns the status runc should exit with, which is the exit
+// status of the process unless runc detached from it.
+func (r *runResult) exitCode() int {
+	if r.Detached {
+		return 0
+	}
+	return r.Status
 }
 
 type runner struct {
@@ -225,15 +487,15 @@ type runner struct {
 	criuOpts        *libcontainer.CriuOpts
 }
 
-func (r *runner) run(config *specs.Process) (int, error) {
+func (r *runner) run(config *specs.Process) (*runResult, error) {
 	if err := r.checkTerminal(config); err != nil {
 		r.destroy()
-		return -1, err
+		return nil, err
 	}
 	process, err := newProcess(*config)
 	if err != nil {
 		r.destroy()
-		return -1, err
+		return nil, err
 	}
 	if len(r.listenFDs) > 0 {
 		process.Env = append(process.Env, fmt.Sprintf("LISTEN_FDS=%d", len(r.listenFDs)), "LISTEN_PID=1")
@@ -246,12 +508,12 @@ func (r *runner) run(config *specs.Process) (int, error) {
 	rootuid, err := r.container.Config().HostRootUID()
 	if err != nil {
 		r.destroy()
-		return -1, err
+		return nil, err
 	}
 	rootgid, err := r.container.Config().HostRootGID()
 	if err != nil {
 		r.destroy()
-		return -1, err
+		return nil, err
 	}
 	var (
 		detach = r.detach || (r.action == CT_ACT_CREATE)
@@ -263,50 +525,58 @@ func (r *runner) run(config *specs.Process) (int, error) {
 	tty, err := setupIO(process, rootuid, rootgid, config.Terminal, detach, r.consoleSocket)
 	if err != nil {
 		r.destroy()
-		return -1, err
+		return nil, err
 	}
 	defer tty.Close()
 
+	start := time.Now()
 	switch r.action {
 	case CT_ACT_CREATE:
 		err = r.container.Start(process)
+		utils.LogTiming("start container", start)
 	case CT_ACT_RESTORE:
 		err = r.container.Restore(process, r.criuOpts)
+		utils.LogTiming("restore container", start)
 	case CT_ACT_RUN:
 		err = r.container.Run(process)
+		utils.LogTiming("run container", start)
 	default:
 		panic("Unknown action")
 	}
 	if err != nil {
 		r.destroy()
-		return -1, err
+		return nil, err
 	}
 	if err := tty.waitConsole(); err != nil {
 		r.terminate(process)
 		r.destroy()
-		return -1, err
+		return nil, err
 	}
 	if err = tty.ClosePostStart(); err != nil {
 		r.terminate(process)
 		r.destroy()
-		return -1, err
+		return nil, err
 	}
 	if r.pidFile != "" {
 		if err = createPidFile(r.pidFile, process); err != nil {
 			r.terminate(process)
 			r.destroy()
-			return -1, err
+			return nil, err
 		}
 	}
-	status, err := handler.forward(process, tty, detach)
+	result := &runResult{Detached: detach}
+	result.Status, err = handler.forward(process, tty, detach)
 	if err != nil {
+		// whether or not we detached, the container is left without its
+		// process so do not leave it behind.
 		r.terminate(process)
+		r.destroy()
+		return nil, err
 	}
-	if detach {
-		return 0, nil
+	if !detach {
+		r.destroy()
 	}
-	r.destroy()
-	return status, err
+	return result, nil
 }
 
 func (r *runner) destroy() {
@@ -353,10 +623,10 @@ const (
 	CT_ACT_RESTORE
 )
 
-func startContainer(context *cli.Context, spec *specs.Spec, action CtAct, criuOpts *libcontainer.CriuOpts) (int, error) {
+func startContainer(context *cli.Context, spec *specs.Spec, action CtAct, criuOpts *libcontainer.CriuOpts) (*runResult, error) {
 	id := context.Args().First()
 	if id == "" {
-		return -1, errEmptyID
+		return nil, errEmptyID
 	}
 
 	notifySocket := newNotifySocket(context, os.Getenv("NOTIFY_SOCKET"), id)
@@ -364,10 +634,14 @@ func startContainer(context *cli.Context, spec *specs.Spec, action CtAct, criuOp
 		notifySocket.setupSpec(context, spec)
 	}
 
+	if err := setupInit(spec); err != nil {
+		return nil, err
+	}
 	container, err := createContainer(context, id, spec)
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
+	setupHostEnv(&spec.Process, spec.Annotations)
 
 	if notifySocket != nil {
 		notifySocket.setupSocket()
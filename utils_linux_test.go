@@ -0,0 +1,234 @@
+// +build linux
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/urfave/cli"
+)
+
+// mockContainer is a container whose Run only reports runErr, for driving the
+// runner without starting anything.
+type mockContainer struct {
+	libcontainer.Container
+	runErr    error
+	destroyed int
+}
+
+func (m *mockContainer) Config() configs.Config {
+	return configs.Config{}
+}
+
+func (m *mockContainer) Run(process *libcontainer.Process) error {
+	return m.runErr
+}
+
+func (m *mockContainer) Destroy() error {
+	m.destroyed++
+	return nil
+}
+
+func newTestRunner(container libcontainer.Container, detach bool) *runner {
+	return &runner{
+		shouldDestroy: true,
+		container:     container,
+		detach:        detach,
+		action:        CT_ACT_RUN,
+	}
+}
+
+var testProcessSpec = specs.Process{
+	Cwd:  "/",
+	Args: []string{"true"},
+}
+
+func TestRunnerRunDetached(t *testing.T) {
+	container := &mockContainer{}
+	result, err := newTestRunner(container, true).run(&testProcessSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Detached {
+		t.Fatal("expected result to be detached")
+	}
+	if code := result.exitCode(); code != 0 {
+		t.Fatalf("expected exit code 0 after detaching but got %d", code)
+	}
+	if container.destroyed != 0 {
+		t.Fatal("expected a detached container not to be destroyed")
+	}
+}
+
+func TestRunnerRunError(t *testing.T) {
+	container := &mockContainer{runErr: errors.New("run failed")}
+	result, err := newTestRunner(container, false).run(&testProcessSpec)
+	if err == nil {
+		t.Fatalf("expected run error but got result %+v", result)
+	}
+	if container.destroyed != 1 {
+		t.Fatalf("expected the container to be destroyed once but it was destroyed %d times", container.destroyed)
+	}
+}
+
+func TestRunnerRunForwardError(t *testing.T) {
+	// the mock starts no process, so forwarding signals to it fails.
+	container := &mockContainer{}
+	if _, err := newTestRunner(container, false).run(&testProcessSpec); err == nil {
+		t.Fatal("expected an error forwarding to a process that was not started")
+	}
+	if container.destroyed != 1 {
+		t.Fatalf("expected the container to be destroyed once but it was destroyed %d times", container.destroyed)
+	}
+}
+
+func TestRunnerRunDetachedForwardError(t *testing.T) {
+	// with a notify socket runc waits for the process even when detached,
+	// which fails as the mock starts no process.
+	container := &mockContainer{}
+	r := newTestRunner(container, true)
+	r.notifySocket = &notifySocket{}
+	if _, err := r.run(&testProcessSpec); err == nil {
+		t.Fatal("expected an error forwarding to a process that was not started")
+	}
+	if container.destroyed != 1 {
+		t.Fatalf("expected the container to be destroyed once but it was destroyed %d times", container.destroyed)
+	}
+}
+
+func TestRunResultExitCode(t *testing.T) {
+	for _, test := range []struct {
+		result   runResult
+		expected int
+	}{
+		{result: runResult{Status: 0}, expected: 0},
+		{result: runResult{Status: 3}, expected: 3},
+		{result: runResult{Status: 128 + int(syscall.SIGKILL)}, expected: 128 + int(syscall.SIGKILL)},
+		// runc exits successfully once it detached, whatever the process does.
+		{result: runResult{Detached: true, Status: 3}, expected: 0},
+	} {
+		if code := test.result.exitCode(); code != test.expected {
+			t.Errorf("%+v: expected exit code %d but got %d", test.result, test.expected, code)
+		}
+	}
+}
+
+func TestSetupHostEnv(t *testing.T) {
+	os.Setenv("RUNC_TEST_HOST_A", "host-a")
+	defer os.Unsetenv("RUNC_TEST_HOST_A")
+	os.Setenv("RUNC_TEST_HOST_B", "host-b")
+	defer os.Unsetenv("RUNC_TEST_HOST_B")
+	os.Unsetenv("RUNC_TEST_HOST_MISSING")
+
+	p := &specs.Process{Env: []string{"RUNC_TEST_HOST_B=spec-b"}}
+	setupHostEnv(p, map[string]string{
+		hostEnvAnnotation: " RUNC_TEST_HOST_A, ,RUNC_TEST_HOST_MISSING,,RUNC_TEST_HOST_B ",
+	})
+	// the spec's entries come last so that they win over the host's.
+	expected := []string{"RUNC_TEST_HOST_A=host-a", "RUNC_TEST_HOST_B=host-b", "RUNC_TEST_HOST_B=spec-b"}
+	if !reflect.DeepEqual(p.Env, expected) {
+		t.Fatalf("expected env %q but got %q", expected, p.Env)
+	}
+
+	p = &specs.Process{Env: []string{"A=1"}}
+	setupHostEnv(p, nil)
+	if expected := []string{"A=1"}; !reflect.DeepEqual(p.Env, expected) {
+		t.Fatalf("expected env %q without the annotation but got %q", expected, p.Env)
+	}
+}
+
+func TestSetupInit(t *testing.T) {
+	f, err := ioutil.TempFile("", "init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	spec := &specs.Spec{
+		Process:     specs.Process{Args: []string{"sleep", "1"}},
+		Mounts:      []specs.Mount{{Destination: "/dev", Type: "tmpfs", Source: "tmpfs"}},
+		Annotations: map[string]string{initAnnotation: f.Name()},
+	}
+	if err := setupInit(spec); err != nil {
+		t.Fatal(err)
+	}
+	if args := []string{initPath, "--", "sleep", "1"}; !reflect.DeepEqual(spec.Process.Args, args) {
+		t.Fatalf("expected args %v but got %v", args, spec.Process.Args)
+	}
+	last := spec.Mounts[len(spec.Mounts)-1]
+	if last.Destination != initPath || last.Source != f.Name() {
+		t.Fatalf("expected %s to be mounted at %s last but got %+v", f.Name(), initPath, last)
+	}
+
+	for _, path := range []string{"init", os.TempDir(), f.Name() + ".missing"} {
+		spec.Annotations[initAnnotation] = path
+		if err := setupInit(spec); err == nil {
+			t.Fatalf("expected error for init %q", path)
+		}
+	}
+}
+
+func TestCreatorCommand(t *testing.T) {
+	var got []string
+	app := cli.NewApp()
+	app.Name = "runc"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "root"},
+		cli.BoolFlag{Name: "debug"},
+	}
+	app.Commands = []cli.Command{{
+		Name: "run",
+		Flags: []cli.Flag{
+			cli.BoolFlag{Name: "detach, d"},
+			cli.StringSliceFlag{Name: "env, e"},
+			cli.StringFlag{Name: "pid-file"},
+		},
+		Action: func(context *cli.Context) error {
+			got = creatorCommand(context)
+			return nil
+		},
+	}}
+	if err := app.Run([]string{"/usr/bin/runc", "--root", "/run/secret-root", "run", "-d", "-e", "DB_PASSWORD=hunter2", "mycontainer"}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"runc", "--root", "run", "--detach", "--env"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %q but got %q", expected, got)
+	}
+}
+
+func TestCheckRootfsSymlinkIntoMount(t *testing.T) {
+	rootfs, err := ioutil.TempDir("", "rootfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootfs)
+	for _, dir := range []string{"bin", "usr"} {
+		if err := os.Mkdir(filepath.Join(rootfs, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// /usr is empty in the rootfs and only filled by the mount.
+	if err := os.Symlink("/usr/bin/busybox", filepath.Join(rootfs, "bin", "sh")); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &specs.Process{Args: []string{"/bin/sh"}}
+	config := &configs.Config{Rootfs: rootfs}
+	if err := checkRootfs(config, p); err == nil {
+		t.Fatal("expected an error for a dangling symlink without mounts")
+	}
+	config.Mounts = []*configs.Mount{{Source: "/usr", Destination: "/usr", Device: "bind"}}
+	if err := checkRootfs(config, p); err != nil {
+		t.Fatalf("expected a symlink into a mount to be accepted but got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandArgs(t *testing.T) {
+	env := []string{"A=1", "B=x=y", "EMPTY="}
+	for _, test := range []struct {
+		arg      string
+		expected string
+	}{
+		{arg: "${A}", expected: "1"},
+		{arg: "${A}-${B}", expected: "1-x=y"},
+		{arg: "${EMPTY}", expected: ""},
+		// only the braced form is expanded.
+		{arg: "$A", expected: "$A"},
+		// unset variables are left for the shell.
+		{arg: "${UNSET}", expected: "${UNSET}"},
+		// $${VAR} escapes the reference.
+		{arg: "$${A}", expected: "${A}"},
+		{arg: "$${A}${A}", expected: "${A}1"},
+		{arg: "${1A}", expected: "${1A}"},
+	} {
+		got := expandArgs([]string{"echo", test.arg}, env)
+		if expected := []string{"echo", test.expected}; !reflect.DeepEqual(got, expected) {
+			t.Errorf("expandArgs(%q): expected %q but got %q", test.arg, expected, got)
+		}
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	env := mergeEnv([]string{"A=0", "C=3"}, []string{"A=1", "B=2", "C=", "B=4"})
+	if expected := []string{"A=1", "C=", "B=4"}; !reflect.DeepEqual(env, expected) {
+		t.Fatalf("expected %q but got %q", expected, env)
+	}
+	if env := mergeEnv([]string{"A=0"}, nil); !reflect.DeepEqual(env, []string{"A=0"}) {
+		t.Fatalf("expected env to be unchanged but got %q", env)
+	}
+}
+
+func TestCheckOverrides(t *testing.T) {
+	for _, test := range []struct {
+		cwd   string
+		env   []string
+		valid bool
+	}{
+		{valid: true},
+		{cwd: "/tmp", env: []string{"A=1", "B="}, valid: true},
+		{cwd: "tmp"},
+		{cwd: "./tmp"},
+		{env: []string{"A=1", "FOO"}},
+		{env: []string{"=1"}},
+	} {
+		err := checkOverrides(test.cwd, test.env)
+		if test.valid && err != nil {
+			t.Errorf("cwd %q env %q: unexpected error %v", test.cwd, test.env, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("cwd %q env %q: expected an error", test.cwd, test.env)
+		}
+	}
+}
+
+func TestBundlePath(t *testing.T) {
+	bundle, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(bundle)
+	if err := os.Symlink("/etc/passwd", filepath.Join(bundle, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := bundlePath(bundle, "env.list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(bundle, "env.list"); path != expected {
+		t.Fatalf("expected %s but got %s", expected, path)
+	}
+	// symlinks are resolved as if the bundle was the root.
+	path, err = bundlePath(bundle, "link")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(bundle, "etc", "passwd"); path != expected {
+		t.Fatalf("expected %s but got %s", expected, path)
+	}
+	for _, name := range []string{"/etc/passwd", "../../../etc/passwd"} {
+		if path, err := bundlePath(bundle, name); err == nil {
+			t.Errorf("expected %s to be refused but got %s", name, path)
+		}
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("# comment\n\nA=1\n  B=two words  \nC=x=y\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	env, err := readEnvFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"A=1", "B=two words", "C=x=y"}; !reflect.DeepEqual(env, expected) {
+		t.Fatalf("expected %q but got %q", expected, env)
+	}
+
+	if err := ioutil.WriteFile(f.Name(), []byte("A=1\nNOVALUE\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readEnvFile(f.Name()); err == nil {
+		t.Fatal("expected error for a line without =")
+	}
+	if _, err := readEnvFile(f.Name() + ".missing"); err == nil {
+		t.Fatal("expected error for a missing env file")
+	}
+}